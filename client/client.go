@@ -3,6 +3,7 @@ package client
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/pkg/errors"
@@ -25,19 +26,24 @@ type Client struct {
 	secret       string       // required to access the hanko api
 	hmacApiKeyId string       // contains the api key id when HMAC is used
 	httpClient   *http.Client // for http communication with the hanko server
-	log          *log.Logger  // logrus logger
+	retryPolicy  RetryPolicy  // decides whether failed requests are retried, no retries if nil
+	middlewares  []Middleware // wrap every call made through Request, outermost first
+	log          Logger       // used for logging, writes to a logrus.Logger by default
+	redactor     *Redactor    // redacts request and response bodies before they are logged
 }
 
 // NewClient returns a new basic hanko Client. pass in the base url (e.g. https://api.hanko.io) and your api secret.
 func NewClient(baseUrl string, secret string) *Client {
+	logger := log.New()
+	logger.SetFormatter(&log.JSONFormatter{})
 	client := &Client{
 		baseUrl:    baseUrl,
 		secret:     secret,
 		apiVersion: "v1",
-		log:        log.New(),
+		log:        NewLogrusLogger(logger),
+		redactor:   NewRedactor(),
 		httpClient: &http.Client{Timeout: time.Second * 10},
 	}
-	client.log.SetFormatter(&log.JSONFormatter{})
 	return client
 }
 
@@ -57,29 +63,78 @@ func (c *Client) SetHttpClient(httpClient *http.Client) {
 	c.httpClient = httpClient
 }
 
+// SetRetryPolicy sets the RetryPolicy used to decide whether failed requests should be attempted again. Pass nil to
+// disable retries, which is the default.
+func (c *Client) SetRetryPolicy(retryPolicy RetryPolicy) {
+	c.retryPolicy = retryPolicy
+}
+
+// AddMiddleware appends the given Middleware to the chain of Middleware wrapping every call made through Request.
+// Middleware added first is called first.
+func (c *Client) AddMiddleware(middleware ...Middleware) {
+	c.middlewares = append(c.middlewares, middleware...)
+}
+
 // SetLogger allows you to set a custom logrus.Logger.
 func (c *Client) SetLogger(logger *log.Logger) {
+	c.log = NewLogrusLogger(logger)
+}
+
+// SetLogAdapter allows you to set a custom Logger, e.g. a slog.Logger wrapped using NewSlogLogger. Use NewNopLogger
+// to disable logging.
+func (c *Client) SetLogAdapter(logger Logger) {
 	c.log = logger
 }
 
-// SetLogWriter allows you to change the log output to the given io.Writer.
+// SetRedactor allows you to set the Redactor used to redact secrets and personal data from request and response
+// bodies before they are logged. The default is a Redactor created by NewRedactor. Pass nil to disable redaction.
+func (c *Client) SetRedactor(redactor *Redactor) {
+	c.redactor = redactor
+}
+
+// SetLogWriter allows you to change the log output to the given io.Writer. It only takes effect if the Client
+// writes to a logrus.Logger.
 func (c *Client) SetLogWriter(out io.Writer) {
-	c.log.Out = out
+	if logger, ok := c.log.(*logrusLogger); ok {
+		logger.logger.Out = out
+	}
 }
 
-// SetLogLevel allows you to change the log level. The default is logrus.InfoLevel.
+// DiscardLogs disables logging. If the Client writes to a logrus.Logger, its output is set to ioutil.Discard, so that
+// logging can be enabled again using SetLogWriter. Otherwise, the Logger is replaced by NewNopLogger.
+func (c *Client) DiscardLogs() {
+	if logger, ok := c.log.(*logrusLogger); ok {
+		logger.logger.Out = ioutil.Discard
+		return
+	}
+	c.log = NewNopLogger()
+}
+
+// SetLogLevel allows you to change the log level. The default is logrus.InfoLevel. It only takes effect if the
+// Client writes to a logrus.Logger.
 func (c *Client) SetLogLevel(level log.Level) {
-	c.log.SetLevel(level)
+	if logger, ok := c.log.(*logrusLogger); ok {
+		logger.logger.SetLevel(level)
+	}
 }
 
-// SetLogFormatter allows you to set a custom log formatter. The default is logrus.JSONFormatter.
+// SetLogFormatter allows you to set a custom log formatter. The default is logrus.JSONFormatter. It only takes effect
+// if the Client writes to a logrus.Logger.
 func (c *Client) SetLogFormatter(formatter log.Formatter) {
-	c.log.SetFormatter(formatter)
+	if logger, ok := c.log.(*logrusLogger); ok {
+		logger.logger.SetFormatter(formatter)
+	}
 }
 
 // NewHttpRequest encodes the given requestBody, creates a new HTTP request using the http.NewRequest method and
 // sets the authorization and content-type headers.
 func (c *Client) NewHttpRequest(method string, requestUrl string, requestBody interface{}) (httpRequest *http.Request, err error) {
+	return c.NewHttpRequestContext(context.Background(), method, requestUrl, requestBody)
+}
+
+// NewHttpRequestContext is like NewHttpRequest but creates the HTTP request using the http.NewRequestWithContext
+// method, so that the given context.Context controls the lifetime of the request.
+func (c *Client) NewHttpRequestContext(ctx context.Context, method string, requestUrl string, requestBody interface{}) (httpRequest *http.Request, err error) {
 	parsedRequestUrl, err := url.Parse(requestUrl)
 	if err != nil {
 		return nil, errors.Errorf("failed to parse url: '%s'", requestUrl)
@@ -93,7 +148,7 @@ func (c *Client) NewHttpRequest(method string, requestUrl string, requestBody in
 		}
 	}
 
-	httpRequest, err = http.NewRequest(method, parsedRequestUrl.String(), encodedRequestBody)
+	httpRequest, err = http.NewRequestWithContext(ctx, method, parsedRequestUrl.String(), encodedRequestBody)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to create a new http request")
 	}
@@ -106,7 +161,51 @@ func (c *Client) NewHttpRequest(method string, requestUrl string, requestBody in
 }
 
 // HttpClientDo calls the API with the specified http.Request and returns an error if the status code was not 2xx.
+//
+// If a RetryPolicy has been set, failed attempts are repeated as long as the RetryPolicy permits. The authorization
+// header is recalculated for each attempt.
 func (c *Client) HttpClientDo(httpRequest *http.Request) (httpResponse *http.Response, err error) {
+	for attempt := 1; ; attempt++ {
+		httpResponse, err = c.httpClientDoOnce(httpRequest)
+		if err == nil || c.retryPolicy == nil {
+			return httpResponse, err
+		}
+
+		retry, wait := c.retryPolicy.Retry(attempt, httpRequest, httpResponse, err)
+		if !retry {
+			return httpResponse, err
+		}
+
+		c.log.Warn("retrying hanko api request", Fields{
+			"method":  httpRequest.Method,
+			"url":     httpRequest.URL.String(),
+			"attempt": attempt,
+			"wait":    wait.String(),
+		}.WithError(err))
+
+		if httpResponse != nil {
+			_, _ = io.Copy(ioutil.Discard, httpResponse.Body)
+			httpResponse.Body.Close()
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-httpRequest.Context().Done():
+			timer.Stop()
+			return nil, errors.Wrap(httpRequest.Context().Err(), "could not do request")
+		case <-timer.C:
+		}
+
+		httpRequest, err = c.renewHttpRequest(httpRequest)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// httpClientDoOnce makes a single attempt to call the API with the specified http.Request and returns an error if the
+// status code was not 2xx.
+func (c *Client) httpClientDoOnce(httpRequest *http.Request) (httpResponse *http.Response, err error) {
 	httpResponse, err = c.httpClient.Do(httpRequest)
 	if err != nil {
 		return nil, errors.Wrap(err, "could not do request")
@@ -119,6 +218,28 @@ func (c *Client) HttpClientDo(httpRequest *http.Request) (httpResponse *http.Res
 	return httpResponse, nil
 }
 
+// renewHttpRequest returns a copy of the given http.Request with a fresh body and, since an HMAC is only valid once,
+// a recalculated authorization header.
+func (c *Client) renewHttpRequest(httpRequest *http.Request) (*http.Request, error) {
+	renewedRequest := httpRequest.Clone(httpRequest.Context())
+	body := new(bytes.Buffer)
+	if httpRequest.GetBody != nil {
+		bodyReader, err := httpRequest.GetBody()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to renew http request body")
+		}
+		_, err = body.ReadFrom(bodyReader)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to renew http request body")
+		}
+		renewedRequest.Body = ioutil.NopCloser(bytes.NewReader(body.Bytes()))
+	}
+	if renewedRequest.Header.Get("Authorization") != "" {
+		renewedRequest.Header.Set("Authorization", c.getAuthorizationHeader(httpRequest.Method, httpRequest.URL, body))
+	}
+	return renewedRequest, nil
+}
+
 // getAuthorizationHeader calculates an HMAC with the specified values for method, url and body. If the client has been
 // created without the client.WithHmac option, the api secret will be used for authentication. Returns the
 // HTTP authorization header as a string.
@@ -139,23 +260,23 @@ func (c *Client) getAuthorizationHeader(method string, url *url.URL, body *bytes
 }
 
 // decodeHttpResponse decodes the httpResponse into the given responseType.
-func (c *Client) decodeHttpResponse(httpResponse *http.Response, responseType interface{}, ctxLogger *log.Entry) (err error) {
+func (c *Client) decodeHttpResponse(httpResponse *http.Response, responseType interface{}, logFields Fields) (err error) {
 	responseTypeName := reflect.TypeOf(responseType).String()
 	defer httpResponse.Body.Close()
 	body, err := ioutil.ReadAll(httpResponse.Body)
 	if err != nil {
 		return errors.Wrap(err, "failed to read http response body")
 	}
-	ctxLogger.WithField("raw_response", string(body)).Debug("http response body read")
+	c.log.Debug("http response body read", logFields.With(Fields{"raw_response": c.redactor.RedactJSON(body)}))
 	err = json.Unmarshal(body, responseType)
 	if err != nil {
-		ctxLogger.WithField("response_type", responseTypeName).Error("failed to decode http response")
+		c.log.Error("failed to decode http response", logFields.With(Fields{"response_type": responseTypeName}))
 		return errors.Wrap(err, "failed to decode http response")
 	}
-	ctxLogger.WithFields(log.Fields{
-		"decoded_response": fmt.Sprintf("%+v", responseType),
+	c.log.Debug("http response body decoded", logFields.With(Fields{
+		"decoded_response": c.redactor.Redact(responseType),
 		"response_type":    responseTypeName,
-	}).Debug("http response body decoded")
+	}))
 	return nil
 }
 
@@ -163,52 +284,98 @@ func (c *Client) decodeHttpResponse(httpResponse *http.Response, responseType in
 // action is currently performed. Parameters method, requestUrl and requestBody are used to construct the request.
 // The body of the API response will be decoded into the given responseType. On error, returns an ApiError.
 func (c *Client) Request(action string, method string, requestUrl string, requestBody interface{}, responseType interface{}) *ApiError {
-	ctxLogger := c.log.WithFields(log.Fields{
-		"action": action,
-		"method": method,
-		"url":    requestUrl,
-	})
+	return c.RequestContext(context.Background(), action, method, requestUrl, requestBody, responseType)
+}
 
-	ctxLogger.Debug("new http request")
+// RequestContext is like Request but uses the given context.Context for the request to the API. If the context is
+// canceled or its deadline is exceeded before the API call is completed, the request is aborted and an ApiError is
+// returned.
+func (c *Client) RequestContext(ctx context.Context, action string, method string, requestUrl string, requestBody interface{}, responseType interface{}) *ApiError {
+	call := &Call{
+		Action:       action,
+		Method:       method,
+		Url:          requestUrl,
+		RequestBody:  requestBody,
+		Header:       http.Header{},
+		ResponseBody: responseType,
+	}
 
-	if requestBody != nil {
-		ctxLogger.WithFields(log.Fields{
-			"request_type": reflect.TypeOf(requestBody).String(),
-			"request":      fmt.Sprintf("%+v", requestBody),
-		}).Debug("got request body")
+	handler := c.handle
+	for i := len(c.middlewares) - 1; i >= 0; i-- {
+		handler = c.middlewares[i](handler)
 	}
 
-	httpRequest, err := c.NewHttpRequest(method, requestUrl, requestBody)
+	return handler(ctx, call)
+}
+
+// handle performs the given Call. It is the innermost Handler of the middleware chain.
+func (c *Client) handle(ctx context.Context, call *Call) *ApiError {
+	logFields := Fields{
+		"action": call.Action,
+		"method": call.Method,
+		"url":    call.Url,
+	}
+
+	c.log.Debug("new http request", logFields)
+
+	if call.RequestBody != nil {
+		c.log.Debug("got request body", logFields.With(Fields{
+			"request_type": reflect.TypeOf(call.RequestBody).String(),
+			"request":      c.redactor.Redact(call.RequestBody),
+		}))
+	}
+
+	httpRequest, err := c.NewHttpRequestContext(ctx, call.Method, call.Url, call.RequestBody)
 	if err != nil {
-		ctxLogger.WithError(err).Error("failed to create http request")
-		return WrapError(err)
+		c.log.Error("failed to create http request", logFields.WithError(err))
+		return wrapError(err, ErrInternal)
+	}
+	for key, values := range call.Header {
+		if http.CanonicalHeaderKey(key) != "Authorization" {
+			httpRequest.Header[http.CanonicalHeaderKey(key)] = values
+		}
 	}
 
 	httpResponse, err := c.HttpClientDo(httpRequest)
+	call.HttpResponse = httpResponse
 	if err != nil {
 		if httpResponse != nil {
 			apiErr := &ApiError{}
-			decErr := c.decodeHttpResponse(httpResponse, apiErr, ctxLogger)
+			decErr := c.decodeHttpResponse(httpResponse, apiErr, logFields)
 			if decErr == nil {
-				ctxLogger.WithError(err).WithFields(log.Fields{
+				if apiErr.StatusCode == 0 {
+					apiErr.StatusCode = httpResponse.StatusCode
+					apiErr.StatusText = http.StatusText(httpResponse.StatusCode)
+				}
+				c.log.Error(apiErr.Message, logFields.WithError(err).With(Fields{
 					"debug_message": apiErr.DebugMessage,
 					"details":       apiErr.Details,
-				}).Error(apiErr.Message)
+				}))
 				return apiErr
 			}
+			c.log.Error("hanko api call failed", logFields.WithError(err))
+			return &ApiError{
+				Message:      "unexpected api response",
+				DebugMessage: err.Error(),
+				StatusText:   http.StatusText(httpResponse.StatusCode),
+				StatusCode:   httpResponse.StatusCode,
+				cause:        err,
+			}
 		}
-		ctxLogger.WithError(err).Error("hanko api call failed")
+		c.log.Error("hanko api call failed", logFields.WithError(err))
 		return WrapError(err)
 	}
 
-	if responseType != nil {
-		err = c.decodeHttpResponse(httpResponse, responseType, ctxLogger)
+	if call.ResponseBody != nil {
+		err = c.decodeHttpResponse(httpResponse, call.ResponseBody, logFields)
 		if err != nil {
-			ctxLogger.WithError(err).Error("failed to decode the hanko api response")
-			return WrapError(err)
+			c.log.Error("failed to decode the hanko api response", logFields.WithError(err))
+			return wrapError(err, ErrDecode)
 		}
+	} else {
+		httpResponse.Body.Close()
 	}
 
-	ctxLogger.Info("hanko api call succeeded")
+	c.log.Info("hanko api call succeeded", logFields)
 	return nil
 }
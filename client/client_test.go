@@ -1,14 +1,17 @@
 package client
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net"
 	"net/http"
 	"net/http/httptest"
 	"regexp"
 	"testing"
+	"time"
 )
 
 const (
@@ -121,3 +124,86 @@ func TestHankoApiClient_Do(t *testing.T) {
 	}
 	ts.Close()
 }
+
+func runSlowTestApi(delay time.Duration) *httptest.Server {
+	return httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.Copy(ioutil.Discard, r.Body)
+			select {
+			case <-time.After(delay):
+				w.WriteHeader(http.StatusOK)
+			case <-r.Context().Done():
+			}
+		}),
+	)
+}
+
+func TestHankoApiClient_NewHttpRequestContext(t *testing.T) {
+	client := getTestClient()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	request, err := client.NewHttpRequestContext(ctx, http.MethodGet, "/test", nil)
+	if err != nil {
+		t.Error(err)
+		t.Fail()
+	}
+	if request.Context() != ctx {
+		t.Error("http request does not carry the given context")
+		t.Fail()
+	}
+}
+
+func TestHankoApiClient_RequestContext(t *testing.T) {
+	ts := runSlowTestApi(10 * time.Millisecond)
+	defer ts.Close()
+	client := NewClient(ts.URL, testApiSecret)
+	client.SetLogWriter(ioutil.Discard)
+
+	err := client.RequestContext(context.Background(), "test", http.MethodGet, ts.URL, nil, nil)
+	if err != nil {
+		t.Errorf("no error expected, got: %s", err)
+		t.Fail()
+	}
+}
+
+func TestHankoApiClient_RequestContextCanceled(t *testing.T) {
+	ts := runSlowTestApi(5 * time.Second)
+	defer ts.Close()
+	client := NewClient(ts.URL, testApiSecret)
+	client.SetLogWriter(ioutil.Discard)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	err := client.RequestContext(ctx, "test", http.MethodGet, ts.URL, nil, nil)
+	if err == nil {
+		t.Error("error expected")
+		t.Fail()
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("request was not canceled, took %s", elapsed)
+		t.Fail()
+	}
+}
+
+func TestHankoApiClient_RequestContextDeadlineExceeded(t *testing.T) {
+	ts := runSlowTestApi(5 * time.Second)
+	defer ts.Close()
+	client := NewClient(ts.URL, testApiSecret)
+	client.SetLogWriter(ioutil.Discard)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := client.RequestContext(ctx, "test", http.MethodGet, ts.URL, nil, nil)
+	if err == nil {
+		t.Error("error expected")
+		t.Fail()
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("request did not honour the deadline, took %s", elapsed)
+		t.Fail()
+	}
+}
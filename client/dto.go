@@ -24,6 +24,9 @@ type ApiError struct {
 	DebugMessage string `json:"debug_message"` // optionally contains a technical error message
 	StatusText   string `json:"status_text"`   // contains the http status text which corresponds to the StatusCode
 	StatusCode   int    `json:"status_code"`   // contains the http status code
+
+	kind  error // one of the sentinel errors, e.g. ErrNotFound; derived from StatusCode if not set
+	cause error // the underlying error, if the ApiError has been caused by an error within the SDK
 }
 
 // Error fulfills the go error interface and returns all error details available.
@@ -43,14 +46,64 @@ func (e *ApiError) Error() string {
 	return str
 }
 
+// AsError returns the ApiError as an error. Unlike assigning a *ApiError to a variable of type error, it returns a nil
+// error if the ApiError is nil, e.g.:
+//
+//	var err error = apiErr.AsError()
+//	if err != nil {
+//		...
+//	}
+func (e *ApiError) AsError() error {
+	if e == nil {
+		return nil
+	}
+	return e
+}
+
+// Kind returns the sentinel error describing the category of the ApiError, e.g. ErrNotFound or ErrNetwork. Returns
+// nil if the category is unknown.
+func (e *ApiError) Kind() error {
+	if e == nil {
+		return nil
+	}
+	if e.kind != nil {
+		return e.kind
+	}
+	return kindOfStatusCode(e.StatusCode)
+}
+
+// Is reports whether the ApiError belongs to the category described by the given sentinel error, e.g. ErrNotFound.
+// This allows using errors.Is to check the category of an ApiError.
+func (e *ApiError) Is(target error) bool {
+	kind := e.Kind()
+	return kind != nil && kind == target
+}
+
+// Unwrap returns the underlying error, if the ApiError has been caused by an error within the SDK, e.g. a
+// context.DeadlineExceeded error.
+func (e *ApiError) Unwrap() error {
+	if e == nil {
+		return nil
+	}
+	return e.cause
+}
+
 // WrapError wraps a given error and returns an ApiError. The resulting ApiError has an underlying Internal Server Error
-// (500) per default.
+// (500) per default. Its category (see ApiError.Kind) is derived from the given error, e.g. ErrTimeout if the error
+// was caused by an exceeded deadline, and the given error can be retrieved using errors.Unwrap.
 func WrapError(err error) *ApiError {
+	return wrapError(err, kindOfError(err))
+}
+
+// wrapError wraps the given error into an ApiError of the given category.
+func wrapError(err error, kind error) *ApiError {
 	return &ApiError{
 		Message:      "sdk error",
 		Details:      "an error occurred while processing the request",
 		DebugMessage: err.Error(),
 		StatusText:   "Internal Server Error",
 		StatusCode:   500,
+		kind:         kind,
+		cause:        err,
 	}
 }
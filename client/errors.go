@@ -0,0 +1,144 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+)
+
+// Sentinel errors describing the category of an ApiError. Use errors.Is to check whether an error returned by a
+// client belongs to one of these categories, e.g.:
+//
+//	if errors.Is(err, client.ErrNotFound) {
+//		...
+//	}
+var (
+	// ErrNetwork indicates that the API could not be reached, e.g. due to a refused or reset connection.
+	ErrNetwork = errors.New("network error")
+
+	// ErrTimeout indicates that the API did not respond in time, either because the timeout of the http.Client or the
+	// deadline of the context.Context used for the request was exceeded.
+	ErrTimeout = errors.New("timeout")
+
+	// ErrCanceled indicates that the context.Context used for the request was canceled.
+	ErrCanceled = errors.New("request canceled")
+
+	// ErrDecode indicates that the response of the API could not be decoded.
+	ErrDecode = errors.New("decode error")
+
+	// ErrInternal indicates that an error occurred within the SDK before a request could be sent, e.g. the request body
+	// could not be encoded.
+	ErrInternal = errors.New("internal sdk error")
+
+	// ErrValidation indicates that the API rejected the request as invalid (status 400 or any other 4xx status not
+	// covered by a more specific error).
+	ErrValidation = errors.New("validation error")
+
+	// ErrUnauthorized indicates that the API rejected the credentials of the client or the client is not allowed to
+	// perform the request (status 401 or 403).
+	ErrUnauthorized = errors.New("unauthorized")
+
+	// ErrNotFound indicates that the requested resource does not exist (status 404).
+	ErrNotFound = errors.New("not found")
+
+	// ErrConflict indicates that the request conflicts with the current state of a resource (status 409).
+	ErrConflict = errors.New("conflict")
+
+	// ErrRateLimited indicates that too many requests have been made (status 429).
+	ErrRateLimited = errors.New("rate limited")
+
+	// ErrServer indicates that the API failed to process the request (status 5xx).
+	ErrServer = errors.New("server error")
+)
+
+// kindOfStatusCode returns the sentinel error matching the given HTTP status code, nil for non-error status codes.
+func kindOfStatusCode(statusCode int) error {
+	switch {
+	case statusCode == http.StatusUnauthorized, statusCode == http.StatusForbidden:
+		return ErrUnauthorized
+	case statusCode == http.StatusNotFound:
+		return ErrNotFound
+	case statusCode == http.StatusConflict:
+		return ErrConflict
+	case statusCode == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case statusCode >= 400 && statusCode < 500:
+		return ErrValidation
+	case statusCode >= 500:
+		return ErrServer
+	}
+	return nil
+}
+
+// kindOfError returns the sentinel error matching an error that occurred while calling the API.
+func kindOfError(err error) error {
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.Canceled):
+		return ErrCanceled
+	case errors.Is(err, context.DeadlineExceeded):
+		return ErrTimeout
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return ErrTimeout
+	case errors.As(err, &netErr):
+		return ErrNetwork
+	}
+	return ErrInternal
+}
+
+// IsNetworkError reports whether err indicates that the API could not be reached.
+func IsNetworkError(err error) bool {
+	return errors.Is(err, ErrNetwork)
+}
+
+// IsTimeout reports whether err indicates that the API did not respond in time.
+func IsTimeout(err error) bool {
+	return errors.Is(err, ErrTimeout)
+}
+
+// IsCanceled reports whether err indicates that the request has been canceled.
+func IsCanceled(err error) bool {
+	return errors.Is(err, ErrCanceled)
+}
+
+// IsDecodeError reports whether err indicates that the response of the API could not be decoded.
+func IsDecodeError(err error) bool {
+	return errors.Is(err, ErrDecode)
+}
+
+// IsValidationError reports whether err indicates that the API rejected the request as invalid.
+func IsValidationError(err error) bool {
+	return errors.Is(err, ErrValidation)
+}
+
+// IsUnauthorized reports whether err indicates that the API rejected the credentials or permissions of the client.
+func IsUnauthorized(err error) bool {
+	return errors.Is(err, ErrUnauthorized)
+}
+
+// IsNotFound reports whether err indicates that the requested resource does not exist.
+func IsNotFound(err error) bool {
+	return errors.Is(err, ErrNotFound)
+}
+
+// IsConflict reports whether err indicates that the request conflicts with the current state of a resource.
+func IsConflict(err error) bool {
+	return errors.Is(err, ErrConflict)
+}
+
+// IsRateLimited reports whether err indicates that too many requests have been made.
+func IsRateLimited(err error) bool {
+	return errors.Is(err, ErrRateLimited)
+}
+
+// IsServerError reports whether err indicates that the API failed to process the request.
+func IsServerError(err error) bool {
+	return errors.Is(err, ErrServer)
+}
+
+// IsRetryable reports whether err is likely to be transient, i.e. whether the same request may succeed when made
+// again later. This is the case for network errors, timeouts, rate limiting and server errors.
+func IsRetryable(err error) bool {
+	return IsNetworkError(err) || IsTimeout(err) || IsRateLimited(err) || IsServerError(err)
+}
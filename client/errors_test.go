@@ -0,0 +1,148 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestApiError_Is(t *testing.T) {
+	var tests = []struct {
+		name              string
+		status            int
+		body              string
+		expectedKind      error
+		expectedRetryable bool
+	}{
+		{
+			name:         "bad request",
+			status:       http.StatusBadRequest,
+			body:         `{"message":"invalid","status_code":400}`,
+			expectedKind: ErrValidation,
+		},
+		{
+			name:         "unprocessable entity",
+			status:       http.StatusUnprocessableEntity,
+			body:         `{"message":"invalid","status_code":422}`,
+			expectedKind: ErrValidation,
+		},
+		{
+			name:         "unauthorized",
+			status:       http.StatusUnauthorized,
+			body:         `{"message":"unauthorized","status_code":401}`,
+			expectedKind: ErrUnauthorized,
+		},
+		{
+			name:         "forbidden",
+			status:       http.StatusForbidden,
+			body:         `{"message":"forbidden","status_code":403}`,
+			expectedKind: ErrUnauthorized,
+		},
+		{
+			name:         "not found",
+			status:       http.StatusNotFound,
+			body:         `{"message":"not found","status_code":404}`,
+			expectedKind: ErrNotFound,
+		},
+		{
+			name:         "conflict",
+			status:       http.StatusConflict,
+			body:         `{"message":"conflict","status_code":409}`,
+			expectedKind: ErrConflict,
+		},
+		{
+			name:              "rate limited",
+			status:            http.StatusTooManyRequests,
+			body:              `{"message":"slow down","status_code":429}`,
+			expectedKind:      ErrRateLimited,
+			expectedRetryable: true,
+		},
+		{
+			name:              "server error",
+			status:            http.StatusBadGateway,
+			body:              `<html>Bad Gateway</html>`,
+			expectedKind:      ErrServer,
+			expectedRetryable: true,
+		},
+		{
+			name:         "undecodable response",
+			status:       http.StatusOK,
+			body:         `{"foo":`,
+			expectedKind: ErrDecode,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer ts.Close()
+			client := NewClient(ts.URL, testApiSecret)
+			client.SetLogWriter(ioutil.Discard)
+
+			var err error = client.Request("test", http.MethodGet, ts.URL, nil, &struct{}{})
+			if !errors.Is(err, tt.expectedKind) {
+				t.Errorf("got %v, want error of kind %v", err, tt.expectedKind)
+			}
+			if IsRetryable(err) != tt.expectedRetryable {
+				t.Errorf("got retryable %t, want %t", IsRetryable(err), tt.expectedRetryable)
+			}
+			var apiErr *ApiError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("expected an ApiError, got %T", err)
+			}
+			if tt.expectedKind != ErrDecode && apiErr.StatusCode != tt.status {
+				t.Errorf("got status code %d, want %d", apiErr.StatusCode, tt.status)
+			}
+		})
+	}
+}
+
+func TestApiError_IsTimeoutAndCanceled(t *testing.T) {
+	ts := runSlowTestApi(5 * time.Second)
+	defer ts.Close()
+	client := NewClient(ts.URL, testApiSecret)
+	client.SetLogWriter(ioutil.Discard)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	var err error = client.RequestContext(ctx, "test", http.MethodGet, ts.URL, nil, nil)
+	if !IsTimeout(err) || !IsRetryable(err) {
+		t.Errorf("got %v, want a retryable timeout", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, want it to wrap context.DeadlineExceeded", err)
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	err = client.RequestContext(ctx, "test", http.MethodGet, ts.URL, nil, nil)
+	if !IsCanceled(err) || IsRetryable(err) {
+		t.Errorf("got %v, want a non-retryable cancellation", err)
+	}
+}
+
+func TestApiError_IsNetworkError(t *testing.T) {
+	ts := httptest.NewServer(http.NotFoundHandler())
+	url := ts.URL
+	ts.Close()
+	client := NewClient(url, testApiSecret)
+	client.SetLogWriter(ioutil.Discard)
+
+	var err error = client.Request("test", http.MethodGet, url, nil, nil)
+	if !IsNetworkError(err) || !IsRetryable(err) {
+		t.Errorf("got %v, want a retryable network error", err)
+	}
+}
+
+func TestApiError_Nil(t *testing.T) {
+	var apiErr *ApiError
+	if apiErr.Is(ErrNotFound) || apiErr.Kind() != nil || apiErr.Unwrap() != nil {
+		t.Error("nil ApiError must not belong to any category")
+	}
+}
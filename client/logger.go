@@ -0,0 +1,123 @@
+package client
+
+import (
+	"context"
+	log "github.com/sirupsen/logrus"
+	"log/slog"
+	"sort"
+)
+
+// Fields contains structured data to be logged along with a log message.
+type Fields map[string]interface{}
+
+// With returns a copy of the Fields extended by the given Fields.
+func (f Fields) With(fields Fields) Fields {
+	merged := make(Fields, len(f)+len(fields))
+	for key, value := range f {
+		merged[key] = value
+	}
+	for key, value := range fields {
+		merged[key] = value
+	}
+	return merged
+}
+
+// WithError returns a copy of the Fields extended by the given error.
+func (f Fields) WithError(err error) Fields {
+	return f.With(Fields{"error": err.Error()})
+}
+
+// Logger is used by the Client to log messages. Use one of the adapters NewLogrusLogger, NewSlogLogger or
+// NewNopLogger, or implement the interface to plug in the logging library of your choice.
+type Logger interface {
+	Debug(msg string, fields Fields)
+	Info(msg string, fields Fields)
+	Warn(msg string, fields Fields)
+	Error(msg string, fields Fields)
+}
+
+// logrusLogger is a Logger writing to a logrus.Logger.
+type logrusLogger struct {
+	logger *log.Logger
+}
+
+// NewLogrusLogger returns a Logger which writes to the given logrus.Logger.
+func NewLogrusLogger(logger *log.Logger) Logger {
+	return &logrusLogger{logger: logger}
+}
+
+func (l *logrusLogger) Debug(msg string, fields Fields) {
+	l.logger.WithFields(log.Fields(fields)).Debug(msg)
+}
+
+func (l *logrusLogger) Info(msg string, fields Fields) {
+	l.logger.WithFields(log.Fields(fields)).Info(msg)
+}
+
+func (l *logrusLogger) Warn(msg string, fields Fields) {
+	l.logger.WithFields(log.Fields(fields)).Warn(msg)
+}
+
+func (l *logrusLogger) Error(msg string, fields Fields) {
+	l.logger.WithFields(log.Fields(fields)).Error(msg)
+}
+
+// slogLogger is a Logger writing to a slog.Logger.
+type slogLogger struct {
+	logger *slog.Logger
+}
+
+// NewSlogLogger returns a Logger which writes to the given slog.Logger. Loggers of other libraries providing a
+// slog.Handler, e.g. zap through go.uber.org/zap/exp/zapslog, can be used through this adapter as well.
+func NewSlogLogger(logger *slog.Logger) Logger {
+	return &slogLogger{logger: logger}
+}
+
+func (l *slogLogger) log(level slog.Level, msg string, fields Fields) {
+	ctx := context.Background()
+	if !l.logger.Enabled(ctx, level) {
+		return
+	}
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	attrs := make([]slog.Attr, 0, len(keys))
+	for _, key := range keys {
+		attrs = append(attrs, slog.Any(key, fields[key]))
+	}
+	l.logger.LogAttrs(ctx, level, msg, attrs...)
+}
+
+func (l *slogLogger) Debug(msg string, fields Fields) {
+	l.log(slog.LevelDebug, msg, fields)
+}
+
+func (l *slogLogger) Info(msg string, fields Fields) {
+	l.log(slog.LevelInfo, msg, fields)
+}
+
+func (l *slogLogger) Warn(msg string, fields Fields) {
+	l.log(slog.LevelWarn, msg, fields)
+}
+
+func (l *slogLogger) Error(msg string, fields Fields) {
+	l.log(slog.LevelError, msg, fields)
+}
+
+// nopLogger is a Logger discarding all messages.
+type nopLogger struct{}
+
+// NewNopLogger returns a Logger which discards all messages.
+func NewNopLogger() Logger {
+	return nopLogger{}
+}
+
+func (nopLogger) Debug(string, Fields) {}
+
+func (nopLogger) Info(string, Fields) {}
+
+func (nopLogger) Warn(string, Fields) {}
+
+func (nopLogger) Error(string, Fields) {}
@@ -0,0 +1,122 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	log "github.com/sirupsen/logrus"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func runSuccessTestApi() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{}`))
+	}))
+}
+
+func TestClient_SetLogAdapterSlog(t *testing.T) {
+	ts := runSuccessTestApi()
+	defer ts.Close()
+
+	out := &bytes.Buffer{}
+	client := NewClient(ts.URL, testApiSecret)
+	client.SetLogAdapter(NewSlogLogger(slog.New(slog.NewJSONHandler(out, nil))))
+
+	apiErr := client.Request("test action", http.MethodGet, ts.URL, nil, nil)
+	if apiErr != nil {
+		t.Error(apiErr)
+	}
+
+	entry := map[string]interface{}{}
+	err := json.Unmarshal(out.Bytes(), &entry)
+	if err != nil {
+		t.Fatalf("expected a single json log entry, got: %s", out.String())
+	}
+	if entry["msg"] != "hanko api call succeeded" || entry["level"] != "INFO" || entry["action"] != "test action" {
+		t.Errorf("got unexpected log entry: %s", out.String())
+	}
+}
+
+func TestClient_SetLogger(t *testing.T) {
+	ts := runSuccessTestApi()
+	defer ts.Close()
+
+	out := &bytes.Buffer{}
+	logger := log.New()
+	logger.SetFormatter(&log.TextFormatter{DisableTimestamp: true})
+	client := NewClient(ts.URL, testApiSecret)
+	client.SetLogger(logger)
+	client.SetLogWriter(out)
+	client.SetLogLevel(log.DebugLevel)
+
+	apiErr := client.Request("test action", http.MethodGet, ts.URL, nil, nil)
+	if apiErr != nil {
+		t.Error(apiErr)
+	}
+
+	if !strings.Contains(out.String(), `level=debug msg="new http request" action="test action"`) {
+		t.Errorf("debug message missing in logs: %s", out.String())
+	}
+	if !strings.Contains(out.String(), `level=info msg="hanko api call succeeded" action="test action"`) {
+		t.Errorf("info message missing in logs: %s", out.String())
+	}
+}
+
+func TestClient_SetLogAdapterNop(t *testing.T) {
+	ts := runSuccessTestApi()
+	defer ts.Close()
+
+	out := &bytes.Buffer{}
+	client := NewClient(ts.URL, testApiSecret)
+	client.SetLogWriter(out)
+	client.SetLogAdapter(NewNopLogger())
+	client.SetLogWriter(out)
+
+	apiErr := client.Request("test action", http.MethodGet, ts.URL, nil, nil)
+	if apiErr != nil {
+		t.Error(apiErr)
+	}
+	if out.Len() != 0 {
+		t.Errorf("expected no logs, got: %s", out.String())
+	}
+}
+
+func TestClient_DiscardLogs(t *testing.T) {
+	ts := runSuccessTestApi()
+	defer ts.Close()
+
+	client := NewClient(ts.URL, testApiSecret)
+	client.SetLogLevel(log.DebugLevel)
+	client.DiscardLogs()
+
+	apiErr := client.Request("test action", http.MethodGet, ts.URL, nil, nil)
+	if apiErr != nil {
+		t.Error(apiErr)
+	}
+
+	out := &bytes.Buffer{}
+	client.SetLogWriter(out)
+
+	apiErr = client.Request("test action", http.MethodGet, ts.URL, nil, nil)
+	if apiErr != nil {
+		t.Error(apiErr)
+	}
+	if !strings.Contains(out.String(), `"level":"debug","method":"GET","msg":"new http request"`) {
+		t.Errorf("expected the log level to be kept after discarding logs: %s", out.String())
+	}
+}
+
+func TestFields_With(t *testing.T) {
+	fields := Fields{"action": "test"}
+	extended := fields.With(Fields{"url": "/test"})
+	if len(fields) != 1 {
+		t.Errorf("original fields have been modified: %v", fields)
+	}
+	if extended["action"] != "test" || extended["url"] != "/test" {
+		t.Errorf("got %v", extended)
+	}
+}
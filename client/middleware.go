@@ -0,0 +1,51 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// Call describes a single call to the Hanko Authentication API made through Client.Request. Middleware may inspect and
+// modify a Call before passing it on to the next Handler and inspect the outcome of the call afterwards.
+type Call struct {
+	// Describes the performed action, e.g. "initialize webauthn registration".
+	Action string
+
+	// The HTTP method of the request.
+	Method string
+
+	// The full URL of the request.
+	Url string
+
+	// The request body before it is encoded as JSON, nil if the request has no body.
+	RequestBody interface{}
+
+	// Additional headers to be sent with the request. Headers set here replace headers of the same name set by the
+	// Client, except for the authorization header which is calculated from the request.
+	Header http.Header
+
+	// The value the response body is decoded into, nil if the response body is discarded. It is populated once the
+	// next Handler returned without an ApiError.
+	ResponseBody interface{}
+
+	// The response of the API, nil if no response has been received (yet). Its body has already been consumed.
+	HttpResponse *http.Response
+}
+
+// Handler performs a Call to the Hanko Authentication API and returns an ApiError on failure.
+type Handler func(ctx context.Context, call *Call) *ApiError
+
+// Middleware wraps a Handler in order to add behaviour to every call made through Client.Request, e.g. adding headers,
+// recording timings or rejecting requests. A Middleware rejects a Call by returning an ApiError without calling next.
+//
+// Example:
+//
+//	func timing(next client.Handler) client.Handler {
+//		return func(ctx context.Context, call *client.Call) *client.ApiError {
+//			start := time.Now()
+//			err := next(ctx, call)
+//			log.Printf("%s took %s", call.Action, time.Since(start))
+//			return err
+//		}
+//	}
+type Middleware func(next Handler) Handler
@@ -0,0 +1,122 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func runEchoTestApi(status int) *httptest.Server {
+	return httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			if status >= 400 {
+				_ = json.NewEncoder(w).Encode(&ApiError{Message: "failed", StatusCode: status})
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]string{"traceId": r.Header.Get("X-Trace-Id")})
+		}),
+	)
+}
+
+func TestClient_Middleware(t *testing.T) {
+	ts := runEchoTestApi(http.StatusOK)
+	defer ts.Close()
+	client := NewClient(ts.URL, testApiSecret)
+	client.SetLogWriter(ioutil.Discard)
+
+	var order []string
+	var seen *Call
+	client.AddMiddleware(
+		func(next Handler) Handler {
+			return func(ctx context.Context, call *Call) *ApiError {
+				order = append(order, "first")
+				call.Header.Set("X-Trace-Id", "trace")
+				err := next(ctx, call)
+				seen = call
+				return err
+			}
+		},
+		func(next Handler) Handler {
+			return func(ctx context.Context, call *Call) *ApiError {
+				order = append(order, "second")
+				return next(ctx, call)
+			}
+		},
+	)
+
+	requestBody := map[string]string{"foo": "bar"}
+	response := map[string]string{}
+	err := client.Request("test action", http.MethodPost, ts.URL, requestBody, &response)
+	if err != nil {
+		t.Errorf("no error expected, got: %s", err)
+	}
+	if !reflect.DeepEqual(order, []string{"first", "second"}) {
+		t.Errorf("got middleware order %v, want [first second]", order)
+	}
+	if response["traceId"] != "trace" {
+		t.Errorf("header set by middleware has not been sent, got %+v", response)
+	}
+	if seen.Action != "test action" || seen.Method != http.MethodPost || seen.Url != ts.URL {
+		t.Errorf("got unexpected call %+v", seen)
+	}
+	if !reflect.DeepEqual(seen.RequestBody, requestBody) {
+		t.Errorf("got request body %+v, want %+v", seen.RequestBody, requestBody)
+	}
+	if seen.HttpResponse == nil || seen.HttpResponse.StatusCode != http.StatusOK {
+		t.Errorf("middleware did not see the http response")
+	}
+}
+
+func TestClient_MiddlewareSeesApiError(t *testing.T) {
+	ts := runEchoTestApi(http.StatusNotFound)
+	defer ts.Close()
+	client := NewClient(ts.URL, testApiSecret)
+	client.SetLogWriter(ioutil.Discard)
+
+	var seen *ApiError
+	client.AddMiddleware(func(next Handler) Handler {
+		return func(ctx context.Context, call *Call) *ApiError {
+			seen = next(ctx, call)
+			return seen
+		}
+	})
+
+	err := client.Request("test", http.MethodGet, ts.URL, nil, nil)
+	if err == nil || seen != err {
+		t.Errorf("middleware did not see the api error, got %v", seen)
+	}
+	if seen.StatusCode != http.StatusNotFound {
+		t.Errorf("got status code %d, want %d", seen.StatusCode, http.StatusNotFound)
+	}
+}
+
+func TestClient_MiddlewareRejectsCall(t *testing.T) {
+	called := false
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer ts.Close()
+	client := NewClient(ts.URL, testApiSecret)
+	client.SetLogWriter(ioutil.Discard)
+
+	rejection := &ApiError{Message: "rejected", StatusCode: http.StatusForbidden}
+	client.AddMiddleware(func(next Handler) Handler {
+		return func(ctx context.Context, call *Call) *ApiError {
+			return rejection
+		}
+	})
+
+	err := client.Request("test", http.MethodGet, ts.URL, nil, nil)
+	if err != rejection {
+		t.Errorf("got %v, want %v", err, rejection)
+	}
+	if called {
+		t.Error("rejected call reached the api")
+	}
+}
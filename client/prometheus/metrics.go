@@ -0,0 +1,102 @@
+// Package prometheus provides a client.Middleware collecting Prometheus metrics about the calls to the Hanko
+// Authentication API. It is kept separate from the client package, so that only users of the metrics depend on the
+// Prometheus client library.
+package prometheus
+
+import (
+	"context"
+	"github.com/prometheus/client_golang/prometheus"
+	hankoClient "github.com/teamhanko/hanko-go/client"
+	"strconv"
+	"time"
+)
+
+// Metrics collects Prometheus metrics about the calls made through client.Client.Request. It implements the
+// prometheus.Collector interface and must be registered on a prometheus.Registerer in order to be exported. Add its
+// Middleware to the clients to be measured, e.g.:
+//
+//	metrics := hankoPrometheus.NewMetrics("myapp")
+//	prometheus.MustRegister(metrics)
+//	hankoWebAuthn := webauthn.NewClient(apiUrl, secret).WithMiddleware(metrics.Middleware())
+//
+// All metrics are labeled with the performed action (e.g. "finalize webauthn authentication") and the status code of
+// the API response ("none" if no response has been received). The following metrics are collected:
+//   - hanko_client_requests_total: the number of calls
+//   - hanko_client_request_errors_total: the number of calls that resulted in an ApiError
+//   - hanko_client_request_duration_seconds: a histogram of the call durations
+type Metrics struct {
+	requests *prometheus.CounterVec
+	errors   *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+}
+
+// NewMetrics creates new Metrics. The given namespace is prepended to the metric names, pass an empty string to omit
+// the namespace.
+func NewMetrics(namespace string) *Metrics {
+	labels := []string{"action", "status_code"}
+	return &Metrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "hanko_client",
+			Name:      "requests_total",
+			Help:      "Number of calls to the Hanko Authentication API.",
+		}, labels),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "hanko_client",
+			Name:      "request_errors_total",
+			Help:      "Number of calls to the Hanko Authentication API that resulted in an error.",
+		}, labels),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "hanko_client",
+			Name:      "request_duration_seconds",
+			Help:      "Duration of calls to the Hanko Authentication API.",
+			Buckets:   prometheus.DefBuckets,
+		}, labels),
+	}
+}
+
+// Describe fulfills the prometheus.Collector interface.
+func (m *Metrics) Describe(ch chan<- *prometheus.Desc) {
+	m.requests.Describe(ch)
+	m.errors.Describe(ch)
+	m.duration.Describe(ch)
+}
+
+// Collect fulfills the prometheus.Collector interface.
+func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
+	m.requests.Collect(ch)
+	m.errors.Collect(ch)
+	m.duration.Collect(ch)
+}
+
+// Middleware returns a client.Middleware which records the Metrics for each call. Retries made according to the
+// client.RetryPolicy happen within a single call, so a call is counted once and its measured duration covers all
+// attempts. Add the Middleware first in order to include the time spent in other Middleware as well. The same Metrics
+// may be shared by several clients.
+func (m *Metrics) Middleware() hankoClient.Middleware {
+	return m.measure
+}
+
+// measure wraps the given client.Handler in a client.Handler which records Metrics for each call.
+func (m *Metrics) measure(next hankoClient.Handler) hankoClient.Handler {
+	return func(ctx context.Context, call *hankoClient.Call) *hankoClient.ApiError {
+		start := time.Now()
+		apiErr := next(ctx, call)
+		duration := time.Since(start)
+
+		statusCode := "none"
+		if call.HttpResponse != nil {
+			statusCode = strconv.Itoa(call.HttpResponse.StatusCode)
+		}
+
+		m.requests.WithLabelValues(call.Action, statusCode).Inc()
+		m.duration.WithLabelValues(call.Action, statusCode).Observe(duration.Seconds())
+		if apiErr != nil {
+			m.errors.WithLabelValues(call.Action, statusCode).Inc()
+		}
+
+		return apiErr
+	}
+}
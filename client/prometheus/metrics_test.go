@@ -0,0 +1,54 @@
+package prometheus
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	hankoClient "github.com/teamhanko/hanko-go/client"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMetrics_Middleware(t *testing.T) {
+	status := http.StatusOK
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer ts.Close()
+
+	metrics := NewMetrics("test")
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(metrics)
+
+	client := hankoClient.NewClient(ts.URL, "secret")
+	client.SetLogWriter(ioutil.Discard)
+	client.AddMiddleware(metrics.Middleware())
+
+	_ = client.Request("finalize webauthn authentication", http.MethodPost, ts.URL, nil, nil)
+	_ = client.Request("finalize webauthn authentication", http.MethodPost, ts.URL, nil, nil)
+	status = http.StatusUnauthorized
+	_ = client.Request("finalize webauthn authentication", http.MethodPost, ts.URL, nil, nil)
+
+	expected := `
+# HELP test_hanko_client_requests_total Number of calls to the Hanko Authentication API.
+# TYPE test_hanko_client_requests_total counter
+test_hanko_client_requests_total{action="finalize webauthn authentication",status_code="200"} 2
+test_hanko_client_requests_total{action="finalize webauthn authentication",status_code="401"} 1
+# HELP test_hanko_client_request_errors_total Number of calls to the Hanko Authentication API that resulted in an error.
+# TYPE test_hanko_client_request_errors_total counter
+test_hanko_client_request_errors_total{action="finalize webauthn authentication",status_code="401"} 1
+`
+	err := testutil.GatherAndCompare(registry, strings.NewReader(expected),
+		"test_hanko_client_requests_total", "test_hanko_client_request_errors_total")
+	if err != nil {
+		t.Error(err)
+	}
+
+	if count := testutil.CollectAndCount(metrics, "test_hanko_client_request_duration_seconds"); count != 2 {
+		t.Errorf("got %d duration series, want 2", count)
+	}
+}
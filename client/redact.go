@@ -0,0 +1,114 @@
+package client
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// RedactFunc returns the redacted representation of a value to be logged. The value is one of the types produced by
+// json.Unmarshal when decoding into an interface{}.
+type RedactFunc func(value interface{}) interface{}
+
+// Mask is a RedactFunc which replaces any value with "[REDACTED]".
+func Mask(interface{}) interface{} {
+	return "[REDACTED]"
+}
+
+// MaskEmail is a RedactFunc which only keeps the domain of an email address, e.g. "john.doe@example.com" becomes
+// "[REDACTED]@example.com". Values which are not email addresses are masked entirely.
+func MaskEmail(value interface{}) interface{} {
+	email, ok := value.(string)
+	if !ok {
+		return Mask(value)
+	}
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return Mask(value)
+	}
+	return "[REDACTED]" + email[at:]
+}
+
+// Redactor redacts secrets and personal data from request and response bodies before they are logged. Rules are
+// applied to all JSON object fields with a matching name (case-insensitive), regardless of their nesting level.
+//
+// By default, the following fields are redacted: "email", "salutation", "clientDataJSON", "attestationObject",
+// "signature", the values of the "prf" WebAuthn extension and the large blobs read ("blob") or written ("write") using
+// the largeBlob WebAuthn extension. Use WithRule, WithMaskedFields and WithoutRule to adjust the rules.
+type Redactor struct {
+	rules map[string]RedactFunc
+}
+
+// NewRedactor returns a new Redactor with the default rules.
+func NewRedactor() *Redactor {
+	return (&Redactor{rules: map[string]RedactFunc{}}).
+		WithRule("email", MaskEmail).
+		WithMaskedFields("salutation", "clientDataJSON", "attestationObject", "signature", "prf", "blob", "write")
+}
+
+// WithRule allows you to set the RedactFunc to apply to fields with the given name, replacing any existing rule for
+// that name.
+func (r *Redactor) WithRule(field string, redact RedactFunc) *Redactor {
+	r.rules[strings.ToLower(field)] = redact
+	return r
+}
+
+// WithMaskedFields allows you to add fields which should be replaced entirely, e.g. "userHandle" or "displayName".
+func (r *Redactor) WithMaskedFields(fields ...string) *Redactor {
+	for _, field := range fields {
+		r.WithRule(field, Mask)
+	}
+	return r
+}
+
+// WithoutRule allows you to remove the rule for fields with the given name, including default rules.
+func (r *Redactor) WithoutRule(field string) *Redactor {
+	delete(r.rules, strings.ToLower(field))
+	return r
+}
+
+// Redact encodes the given value as JSON and returns it with all matching fields redacted. A nil Redactor returns the
+// JSON encoding without any redaction.
+func (r *Redactor) Redact(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "[REDACTED: failed to encode value]"
+	}
+	return r.RedactJSON(data)
+}
+
+// RedactJSON returns the given JSON document with all matching fields redacted. Data which is not valid JSON is
+// returned unchanged. A nil Redactor returns the data without any redaction.
+func (r *Redactor) RedactJSON(data []byte) string {
+	if r == nil {
+		return string(data)
+	}
+	var document interface{}
+	err := json.Unmarshal(data, &document)
+	if err != nil {
+		return string(data)
+	}
+	redacted, err := json.Marshal(r.redact(document))
+	if err != nil {
+		return "[REDACTED: failed to encode value]"
+	}
+	return string(redacted)
+}
+
+// redact walks the given decoded JSON document and applies the rules to all object fields.
+func (r *Redactor) redact(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, fieldValue := range v {
+			if redact, ok := r.rules[strings.ToLower(key)]; ok {
+				v[key] = redact(fieldValue)
+			} else {
+				v[key] = r.redact(fieldValue)
+			}
+		}
+	case []interface{}:
+		for i, element := range v {
+			v[i] = r.redact(element)
+		}
+	}
+	return value
+}
@@ -0,0 +1,105 @@
+package client
+
+import (
+	"bytes"
+	log "github.com/sirupsen/logrus"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRedactor_RedactJSON(t *testing.T) {
+	var tests = []struct {
+		name     string
+		redactor *Redactor
+		input    string
+		expected string
+	}{
+		{
+			name:     "default rules",
+			redactor: NewRedactor(),
+			input:    `{"user_id":"1","email":"john.doe@example.com","Salutation":"Dear John"}`,
+			expected: `{"Salutation":"[REDACTED]","email":"[REDACTED]@example.com","user_id":"1"}`,
+		},
+		{
+			name:     "nested fields",
+			redactor: NewRedactor(),
+			input:    `{"id":"1","response":{"clientDataJSON":"abc","attestationObject":"def"},"list":[{"signature":"ghi"}]}`,
+			expected: `{"id":"1","list":[{"signature":"[REDACTED]"}],"response":{"attestationObject":"[REDACTED]","clientDataJSON":"[REDACTED]"}}`,
+		},
+		{
+			name:     "extension outputs",
+			redactor: NewRedactor(),
+			input:    `{"clientExtensionResults":{"credProps":{"rk":true},"prf":{"results":{"first":"abc"}},"largeBlob":{"blob":"def"}}}`,
+			expected: `{"clientExtensionResults":{"credProps":{"rk":true},"largeBlob":{"blob":"[REDACTED]"},"prf":"[REDACTED]"}}`,
+		},
+		{
+			name:     "extension inputs",
+			redactor: NewRedactor(),
+			input:    `{"options":{"extensions":{"largeBlob":{"write":"abc"},"appid":"https://example.com"}}}`,
+			expected: `{"options":{"extensions":{"appid":"https://example.com","largeBlob":{"write":"[REDACTED]"}}}}`,
+		},
+		{
+			name:     "custom rules",
+			redactor: NewRedactor().WithMaskedFields("user_id").WithoutRule("email"),
+			input:    `{"user_id":"1","email":"john.doe@example.com"}`,
+			expected: `{"email":"john.doe@example.com","user_id":"[REDACTED]"}`,
+		},
+		{
+			name:     "no redactor",
+			redactor: nil,
+			input:    `{"email":"john.doe@example.com"}`,
+			expected: `{"email":"john.doe@example.com"}`,
+		},
+		{
+			name:     "invalid json",
+			redactor: NewRedactor(),
+			input:    `Bad Gateway`,
+			expected: `Bad Gateway`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			redacted := tt.redactor.RedactJSON([]byte(tt.input))
+			if redacted != tt.expected {
+				t.Errorf("got %s, want %s", redacted, tt.expected)
+			}
+		})
+	}
+}
+
+func TestClient_RedactsLogs(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"user_id":"1","email":"john.doe@example.com"}`))
+	}))
+	defer ts.Close()
+
+	out := &bytes.Buffer{}
+	client := NewClient(ts.URL, testApiSecret)
+	client.SetLogWriter(out)
+	client.SetLogLevel(log.DebugLevel)
+
+	requestBody := &struct {
+		Email      string `json:"email"`
+		Salutation string `json:"salutation"`
+	}{"john.doe@example.com", "Dear John"}
+	response := map[string]string{}
+	apiErr := client.Request("test", http.MethodPost, ts.URL, requestBody, &response)
+	if apiErr != nil {
+		t.Error(apiErr)
+	}
+
+	if response["email"] != "john.doe@example.com" {
+		t.Errorf("decoded response has been redacted: %v", response)
+	}
+	for _, secret := range []string{"john.doe", "Dear John"} {
+		if strings.Contains(out.String(), secret) {
+			t.Errorf("logs contain %q: %s", secret, out.String())
+		}
+	}
+	if !strings.Contains(out.String(), "[REDACTED]@example.com") {
+		t.Errorf("logs do not contain redacted email: %s", out.String())
+	}
+}
@@ -0,0 +1,171 @@
+package client
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"io"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy decides whether a failed request to the Hanko Authentication API should be attempted again. Set a
+// RetryPolicy on the Client using Client.SetRetryPolicy. Without a RetryPolicy every request is attempted exactly once.
+type RetryPolicy interface {
+	// Retry is called after each failed attempt. The attempt parameter contains the number of attempts made so far,
+	// httpResponse is nil if no response was received and err contains the error of the attempt. Retry returns whether
+	// the request should be attempted again and how long to wait before doing so.
+	Retry(attempt int, httpRequest *http.Request, httpResponse *http.Response, err error) (retry bool, wait time.Duration)
+}
+
+// BackoffRetryPolicy is a RetryPolicy that retries transient errors (i.e. connection errors and responses with status
+// code 429, 502, 503 or 504) using an exponential backoff with jitter. A Retry-After header sent by the API is
+// respected.
+//
+// By default, only idempotent requests (e.g. retrieving, updating or deleting credentials) are retried. Requests that
+// initialize a WebAuthn ceremony or a Passlink are only retried if enabled through WithInitializationRetries. Requests
+// finalizing a ceremony are never retried.
+type BackoffRetryPolicy struct {
+	MaxAttempts          int           // maximum number of attempts, including the first one
+	InitialBackoff       time.Duration // time to wait before the first retry
+	MaxBackoff           time.Duration // upper bound for the time to wait between two attempts
+	Jitter               float64       // fraction (0 to 1) by which the backoff is randomly reduced
+	RetryInitializations bool          // whether requests initializing a ceremony should be retried
+}
+
+// NewBackoffRetryPolicy returns a new BackoffRetryPolicy. It makes at most 3 attempts, starting with a backoff of
+// 100ms which doubles after each attempt up to 2s, with a jitter of 20%.
+func NewBackoffRetryPolicy() *BackoffRetryPolicy {
+	return &BackoffRetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     2 * time.Second,
+		Jitter:         0.2,
+	}
+}
+
+// WithMaxAttempts allows you to set the maximum number of attempts, including the first one.
+func (p *BackoffRetryPolicy) WithMaxAttempts(maxAttempts int) *BackoffRetryPolicy {
+	p.MaxAttempts = maxAttempts
+	return p
+}
+
+// WithBackoff allows you to set the time to wait before the first retry and the maximum time to wait between two
+// attempts. A Retry-After header requesting a longer wait than maxBackoff stops further retries.
+func (p *BackoffRetryPolicy) WithBackoff(initialBackoff time.Duration, maxBackoff time.Duration) *BackoffRetryPolicy {
+	p.InitialBackoff = initialBackoff
+	p.MaxBackoff = maxBackoff
+	return p
+}
+
+// WithJitter allows you to set the fraction (0 to 1) by which the backoff is randomly reduced.
+func (p *BackoffRetryPolicy) WithJitter(jitter float64) *BackoffRetryPolicy {
+	p.Jitter = jitter
+	return p
+}
+
+// WithInitializationRetries allows you to enable retries for requests initializing a WebAuthn ceremony or a Passlink.
+//
+// Note: Retrying a Passlink initialization may result in more than one message being sent to the user.
+func (p *BackoffRetryPolicy) WithInitializationRetries(enabled bool) *BackoffRetryPolicy {
+	p.RetryInitializations = enabled
+	return p
+}
+
+// Retry fulfills the RetryPolicy interface.
+func (p *BackoffRetryPolicy) Retry(attempt int, httpRequest *http.Request, httpResponse *http.Response, err error) (bool, time.Duration) {
+	if attempt >= p.MaxAttempts || !p.isRetryableRequest(httpRequest) {
+		return false, 0
+	}
+
+	if httpResponse == nil {
+		return isTransientError(err), p.backoff(attempt)
+	}
+
+	switch httpResponse.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+	default:
+		return false, 0
+	}
+
+	if retryAfter, ok := parseRetryAfter(httpResponse.Header.Get("Retry-After")); ok {
+		if retryAfter > p.MaxBackoff {
+			return false, 0
+		}
+		return true, retryAfter
+	}
+
+	return true, p.backoff(attempt)
+}
+
+// isRetryableRequest reports whether the given request may be sent more than once.
+func (p *BackoffRetryPolicy) isRetryableRequest(httpRequest *http.Request) bool {
+	switch httpRequest.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return p.RetryInitializations && IsInitialization(httpRequest.Context())
+}
+
+// backoff returns the exponential backoff for the given attempt, randomly reduced by the configured jitter.
+func (p *BackoffRetryPolicy) backoff(attempt int) time.Duration {
+	backoff := float64(p.InitialBackoff) * math.Pow(2, float64(attempt-1))
+	if backoff > float64(p.MaxBackoff) {
+		backoff = float64(p.MaxBackoff)
+	}
+	backoff -= backoff * p.Jitter * rand.Float64()
+	return time.Duration(backoff)
+}
+
+// parseRetryAfter parses the value of a Retry-After header, which contains either a number of seconds or an HTTP date.
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		wait := time.Until(date)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+	return 0, false
+}
+
+// isTransientError reports whether the given error, returned by the http.Client, is likely to go away on retry.
+func isTransientError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return true
+	}
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, syscall.ECONNRESET)
+}
+
+type initializationKey struct{}
+
+// WithInitialization returns a copy of ctx which marks requests made with it as the initialization of a WebAuthn
+// ceremony or a Passlink. Such requests are not idempotent, but are retried by a BackoffRetryPolicy if enabled through
+// BackoffRetryPolicy.WithInitializationRetries.
+func WithInitialization(ctx context.Context) context.Context {
+	return context.WithValue(ctx, initializationKey{}, true)
+}
+
+// IsInitialization reports whether ctx has been marked using WithInitialization.
+func IsInitialization(ctx context.Context) bool {
+	initialization, _ := ctx.Value(initializationKey{}).(bool)
+	return initialization
+}
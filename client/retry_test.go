@@ -0,0 +1,249 @@
+package client
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testRecorder struct {
+	mu             sync.Mutex
+	authorizations []string
+	bodies         []string
+}
+
+func (r *testRecorder) attempts() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.authorizations)
+}
+
+// runFlakyTestApi starts a server which responds with the given failureStatus to the first failures requests and
+// with status 200 afterwards.
+func runFlakyTestApi(failures int, failureStatus int, recorder *testRecorder) *httptest.Server {
+	return httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := ioutil.ReadAll(r.Body)
+			recorder.mu.Lock()
+			recorder.authorizations = append(recorder.authorizations, r.Header.Get("Authorization"))
+			recorder.bodies = append(recorder.bodies, string(body))
+			attempt := len(recorder.authorizations)
+			recorder.mu.Unlock()
+
+			w.Header().Set("Content-Type", "application/json")
+			if attempt <= failures {
+				w.WriteHeader(failureStatus)
+				_, _ = w.Write([]byte(`{"message":"unavailable"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{}`))
+		}),
+	)
+}
+
+func getTestRetryClient(baseUrl string) *Client {
+	client := NewClient(baseUrl, testApiSecret)
+	client.SetHmac(testHmacApiKeyId)
+	client.SetLogWriter(ioutil.Discard)
+	client.SetRetryPolicy(NewBackoffRetryPolicy().WithBackoff(time.Millisecond, 10*time.Millisecond))
+	return client
+}
+
+func TestBackoffRetryPolicy_RetriesIdempotentRequests(t *testing.T) {
+	recorder := &testRecorder{}
+	ts := runFlakyTestApi(2, http.StatusServiceUnavailable, recorder)
+	defer ts.Close()
+	client := getTestRetryClient(ts.URL)
+
+	err := client.Request("test", http.MethodGet, ts.URL+"/credentials", nil, &struct{}{})
+	if err != nil {
+		t.Errorf("no error expected, got: %s", err)
+	}
+	if recorder.attempts() != 3 {
+		t.Errorf("got %d attempts, want 3", recorder.attempts())
+	}
+	seen := map[string]bool{}
+	for _, authorization := range recorder.authorizations {
+		if seen[authorization] {
+			t.Error("authorization header has not been recalculated")
+		}
+		seen[authorization] = true
+	}
+}
+
+func TestBackoffRetryPolicy_StopsAfterMaxAttempts(t *testing.T) {
+	recorder := &testRecorder{}
+	ts := runFlakyTestApi(10, http.StatusBadGateway, recorder)
+	defer ts.Close()
+	client := getTestRetryClient(ts.URL)
+
+	err := client.Request("test", http.MethodDelete, ts.URL+"/credentials/test", nil, nil)
+	if err == nil {
+		t.Error("error expected")
+	}
+	if recorder.attempts() != 3 {
+		t.Errorf("got %d attempts, want 3", recorder.attempts())
+	}
+}
+
+func TestBackoffRetryPolicy_DoesNotRetryNonRetryableStatus(t *testing.T) {
+	recorder := &testRecorder{}
+	ts := runFlakyTestApi(1, http.StatusBadRequest, recorder)
+	defer ts.Close()
+	client := getTestRetryClient(ts.URL)
+
+	err := client.Request("test", http.MethodGet, ts.URL+"/credentials", nil, nil)
+	if err == nil {
+		t.Error("error expected")
+	}
+	if recorder.attempts() != 1 {
+		t.Errorf("got %d attempts, want 1", recorder.attempts())
+	}
+}
+
+func TestBackoffRetryPolicy_Initialization(t *testing.T) {
+	var tests = []struct {
+		name              string
+		ctx               context.Context
+		retryInitializing bool
+		expectedAttempts  int
+	}{
+		{
+			name:             "finalization",
+			ctx:              context.Background(),
+			expectedAttempts: 1,
+		},
+		{
+			name:              "finalization with initialization retries",
+			ctx:               context.Background(),
+			retryInitializing: true,
+			expectedAttempts:  1,
+		},
+		{
+			name:             "initialization",
+			ctx:              WithInitialization(context.Background()),
+			expectedAttempts: 1,
+		},
+		{
+			name:              "initialization with initialization retries",
+			ctx:               WithInitialization(context.Background()),
+			retryInitializing: true,
+			expectedAttempts:  2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := &testRecorder{}
+			ts := runFlakyTestApi(1, http.StatusServiceUnavailable, recorder)
+			defer ts.Close()
+			client := getTestRetryClient(ts.URL)
+			client.SetRetryPolicy(NewBackoffRetryPolicy().
+				WithBackoff(time.Millisecond, 10*time.Millisecond).
+				WithInitializationRetries(tt.retryInitializing))
+
+			requestBody := &struct {
+				Foo string `json:"foo"`
+			}{"bar"}
+			_ = client.RequestContext(tt.ctx, "test", http.MethodPost, ts.URL+"/initialize", requestBody, nil)
+			if recorder.attempts() != tt.expectedAttempts {
+				t.Errorf("got %d attempts, want %d", recorder.attempts(), tt.expectedAttempts)
+			}
+			for _, body := range recorder.bodies {
+				if body != recorder.bodies[0] {
+					t.Errorf("got request body %s on retry, want %s", body, recorder.bodies[0])
+				}
+			}
+		})
+	}
+}
+
+func TestBackoffRetryPolicy_ContextCanceledWhileWaiting(t *testing.T) {
+	recorder := &testRecorder{}
+	ts := runFlakyTestApi(10, http.StatusServiceUnavailable, recorder)
+	defer ts.Close()
+	client := getTestRetryClient(ts.URL)
+	client.SetRetryPolicy(NewBackoffRetryPolicy().WithBackoff(5*time.Second, 5*time.Second))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := client.RequestContext(ctx, "test", http.MethodGet, ts.URL, nil, nil)
+	if err == nil {
+		t.Error("error expected")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("request did not honour the deadline, took %s", elapsed)
+	}
+}
+
+func TestBackoffRetryPolicy_Retry(t *testing.T) {
+	policy := NewBackoffRetryPolicy().WithBackoff(100*time.Millisecond, 2*time.Second).WithJitter(0)
+	request, _ := http.NewRequest(http.MethodGet, "/test", nil)
+	response := func(status int, retryAfter string) *http.Response {
+		header := http.Header{}
+		if retryAfter != "" {
+			header.Set("Retry-After", retryAfter)
+		}
+		return &http.Response{StatusCode: status, Header: header}
+	}
+
+	var tests = []struct {
+		name          string
+		attempt       int
+		response      *http.Response
+		expectedRetry bool
+		expectedWait  time.Duration
+	}{
+		{
+			name:          "first retry",
+			attempt:       1,
+			response:      response(http.StatusServiceUnavailable, ""),
+			expectedRetry: true,
+			expectedWait:  100 * time.Millisecond,
+		},
+		{
+			name:          "second retry",
+			attempt:       2,
+			response:      response(http.StatusGatewayTimeout, ""),
+			expectedRetry: true,
+			expectedWait:  200 * time.Millisecond,
+		},
+		{
+			name:     "max attempts reached",
+			attempt:  3,
+			response: response(http.StatusServiceUnavailable, ""),
+		},
+		{
+			name:          "retry after seconds",
+			attempt:       1,
+			response:      response(http.StatusTooManyRequests, "1"),
+			expectedRetry: true,
+			expectedWait:  time.Second,
+		},
+		{
+			name:     "retry after exceeds max backoff",
+			attempt:  1,
+			response: response(http.StatusTooManyRequests, "60"),
+		},
+		{
+			name:     "not found",
+			attempt:  1,
+			response: response(http.StatusNotFound, ""),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			retry, wait := policy.Retry(tt.attempt, request, tt.response, nil)
+			if retry != tt.expectedRetry {
+				t.Errorf("got retry %t, want %t", retry, tt.expectedRetry)
+			}
+			if retry && wait != tt.expectedWait {
+				t.Errorf("got wait %s, want %s", wait, tt.expectedWait)
+			}
+		})
+	}
+}
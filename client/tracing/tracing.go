@@ -0,0 +1,66 @@
+// Package tracing provides a client.Middleware tracing the calls to the Hanko Authentication API with OpenTelemetry. It
+// is kept separate from the client package, so that only users of the tracing depend on OpenTelemetry.
+package tracing
+
+import (
+	"context"
+	hankoClient "github.com/teamhanko/hanko-go/client"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"net/url"
+)
+
+// tracerName is the name of the OpenTelemetry instrumentation provided by this package.
+const tracerName = "github.com/teamhanko/hanko-go/client/tracing"
+
+// Middleware returns a client.Middleware tracing calls using the given trace.TracerProvider, e.g.
+// otel.GetTracerProvider(). Each call made through client.Client.Request starts a span named after the performed
+// action (e.g. "initialize webauthn registration") and the trace context is propagated to the API using the global
+// propagator (see otel.SetTextMapPropagator). Add it first in order to include the other Middleware in the spans.
+func Middleware(tracerProvider trace.TracerProvider) hankoClient.Middleware {
+	tracer := tracerProvider.Tracer(tracerName)
+	return func(next hankoClient.Handler) hankoClient.Handler {
+		return traced(tracer, next)
+	}
+}
+
+// traced wraps the given client.Handler in a client.Handler which records a span for each call.
+func traced(tracer trace.Tracer, next hankoClient.Handler) hankoClient.Handler {
+	return func(ctx context.Context, call *hankoClient.Call) *hankoClient.ApiError {
+		attributes := []attribute.KeyValue{
+			attribute.String("hanko.action", call.Action),
+			attribute.String("http.request.method", call.Method),
+		}
+		if parsedUrl, err := url.Parse(call.Url); err == nil {
+			attributes = append(attributes,
+				attribute.String("server.address", parsedUrl.Hostname()),
+				attribute.String("url.path", parsedUrl.Path),
+			)
+		}
+
+		ctx, span := tracer.Start(ctx, call.Action, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attributes...))
+		defer span.End()
+
+		otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(call.Header))
+
+		apiErr := next(ctx, call)
+
+		if call.HttpResponse != nil {
+			span.SetAttributes(attribute.Int("http.response.status_code", call.HttpResponse.StatusCode))
+		}
+		if apiErr != nil {
+			span.SetAttributes(
+				attribute.Int("hanko.error.status_code", apiErr.StatusCode),
+				attribute.String("hanko.error.message", apiErr.Message),
+				attribute.String("hanko.error.details", apiErr.Details),
+			)
+			span.RecordError(apiErr)
+			span.SetStatus(codes.Error, apiErr.Message)
+		}
+
+		return apiErr
+	}
+}
@@ -0,0 +1,89 @@
+package tracing
+
+import (
+	hankoClient "github.com/teamhanko/hanko-go/client"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMiddleware(t *testing.T) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
+
+	var tests = []struct {
+		name           string
+		status         int
+		expectedStatus codes.Code
+	}{
+		{
+			name:           "success",
+			status:         http.StatusOK,
+			expectedStatus: codes.Unset,
+		},
+		{
+			name:           "api error",
+			status:         http.StatusConflict,
+			expectedStatus: codes.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var traceparent string
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				traceparent = r.Header.Get("Traceparent")
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(`{"message":"conflict","details":"already exists","status_code":409}`))
+			}))
+			defer ts.Close()
+
+			recorder := tracetest.NewSpanRecorder()
+			client := hankoClient.NewClient(ts.URL, "secret")
+			client.SetLogWriter(ioutil.Discard)
+			client.AddMiddleware(Middleware(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))))
+
+			_ = client.Request("finalize passlink", http.MethodPatch, ts.URL+"/v1/passlink/test/finalize", nil, nil)
+
+			spans := recorder.Ended()
+			if len(spans) != 1 {
+				t.Fatalf("got %d spans, want 1", len(spans))
+			}
+			span := spans[0]
+			if span.Name() != "finalize passlink" {
+				t.Errorf("got span name %s, want finalize passlink", span.Name())
+			}
+			if span.Status().Code != tt.expectedStatus {
+				t.Errorf("got span status %s, want %s", span.Status().Code, tt.expectedStatus)
+			}
+			attributes := map[attribute.Key]attribute.Value{}
+			for _, kv := range span.Attributes() {
+				attributes[kv.Key] = kv.Value
+			}
+			if attributes["http.request.method"].AsString() != http.MethodPatch {
+				t.Errorf("got method attribute %s, want PATCH", attributes["http.request.method"].AsString())
+			}
+			if attributes["url.path"].AsString() != "/v1/passlink/test/finalize" {
+				t.Errorf("got url.path attribute %s", attributes["url.path"].AsString())
+			}
+			if attributes["http.response.status_code"].AsInt64() != int64(tt.status) {
+				t.Errorf("got status code attribute %d, want %d", attributes["http.response.status_code"].AsInt64(), tt.status)
+			}
+			if tt.status >= 400 && attributes["hanko.error.details"].AsString() != "already exists" {
+				t.Errorf("got error details attribute %s, want already exists", attributes["hanko.error.details"].AsString())
+			}
+
+			expectedTraceparent := "00-" + span.SpanContext().TraceID().String() + "-" + span.SpanContext().SpanID().String() + "-01"
+			if traceparent != expectedTraceparent {
+				t.Errorf("got traceparent %s, want %s", traceparent, expectedTraceparent)
+			}
+		})
+	}
+}
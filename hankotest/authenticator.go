@@ -0,0 +1,464 @@
+package hankotest
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/fxamacker/cbor/v2"
+	"github.com/google/uuid"
+	"github.com/teamhanko/hanko-go/webauthn"
+	"github.com/teamhanko/webauthn/protocol"
+	"github.com/teamhanko/webauthn/protocol/webauthncose"
+	"math/big"
+	"sync"
+)
+
+// AttestationFormat is the attestation statement format used by an Authenticator when registering a credential.
+type AttestationFormat string
+
+const (
+	// AttestationNone creates credentials without an attestation statement.
+	AttestationNone AttestationFormat = "none"
+
+	// AttestationPacked creates credentials with a "packed" self attestation, i.e. the attestation statement is signed
+	// using the private key of the new credential.
+	AttestationPacked AttestationFormat = "packed"
+)
+
+// Errors returned by an Authenticator if it cannot perform a ceremony.
+var (
+	// ErrNoCredential indicates that the Authenticator does not hold a credential that may be used for an
+	// authentication.
+	ErrNoCredential = errors.New("no matching credential")
+
+	// ErrCredentialExcluded indicates that the Authenticator already holds one of the credentials excluded from a
+	// registration.
+	ErrCredentialExcluded = errors.New("credential excluded")
+
+	// ErrNotSupported indicates that the options of a ceremony require a feature not supported by the Authenticator,
+	// e.g. a resident key or an algorithm.
+	ErrNotSupported = errors.New("not supported")
+)
+
+// VirtualCredential is a credential held by an Authenticator.
+type VirtualCredential struct {
+	ID           string // the base64url encoded credential ID
+	RelyingParty string // the relying party ID the credential has been registered for
+	UserHandle   []byte // the user ID the credential has been registered for
+	ResidentKey  bool   // whether the credential is discoverable
+	SignCount    uint32 // the current value of the signature counter
+
+	rawId      []byte
+	algorithm  webauthncose.COSEAlgorithmIdentifier
+	privateKey crypto.Signer
+	prfSecret  []byte
+}
+
+// Authenticator is a software authenticator which performs WebAuthn ceremonies without a browser, e.g. for end-to-end
+// tests of registration and authentication flows. It consumes the options returned by the initialization methods of
+// the webauthn.Client and produces the requests to be passed to the corresponding finalization methods:
+//
+//	authenticator := hankotest.NewAuthenticator()
+//	initialization, _ := client.InitializeRegistration(request)
+//	finalization, err := authenticator.Register(initialization)
+//	credential, _ := client.FinalizeRegistration(finalization)
+//
+// The Authenticator acts as both, client (browser) and authenticator. The client data it creates contains the origin
+// configured using WithOrigin.
+type Authenticator struct {
+	mu                 sync.Mutex
+	origin             string
+	aaguid             uuid.UUID
+	algorithm          webauthncose.COSEAlgorithmIdentifier
+	attestation        AttestationFormat
+	attachment         webauthn.AuthenticatorAttachment
+	signCount          uint32
+	signCountIncrement uint32
+	userPresent        bool
+	userVerified       bool
+	residentKeys       bool
+	credentials        []*VirtualCredential
+}
+
+// NewAuthenticator creates a new Authenticator. Per default, the Authenticator creates ES256 credentials without
+// attestation, supports resident keys, sets the user present and user verified flags and increments the signature
+// counter of a credential by 1 on every authentication. The origin defaults to DefaultOrigin.
+func NewAuthenticator() *Authenticator {
+	return &Authenticator{
+		origin:             DefaultOrigin,
+		algorithm:          webauthncose.AlgES256,
+		attestation:        AttestationNone,
+		signCountIncrement: 1,
+		userPresent:        true,
+		userVerified:       true,
+		residentKeys:       true,
+	}
+}
+
+// WithOrigin sets the origin included in the client data, e.g. "https://example.com".
+func (a *Authenticator) WithOrigin(origin string) *Authenticator {
+	a.origin = origin
+	return a
+}
+
+// WithAaguid sets the AAGUID of the Authenticator included in the attested credential data of new credentials.
+func (a *Authenticator) WithAaguid(aaguid uuid.UUID) *Authenticator {
+	a.aaguid = aaguid
+	return a
+}
+
+// WithAlgorithm sets the algorithm of the keys of new credentials. Supported algorithms are webauthncose.AlgES256,
+// webauthncose.AlgRS256 and webauthncose.AlgEdDSA.
+func (a *Authenticator) WithAlgorithm(algorithm webauthncose.COSEAlgorithmIdentifier) *Authenticator {
+	a.algorithm = algorithm
+	return a
+}
+
+// WithAttestation sets the AttestationFormat used when registering credentials. The format is used regardless of the
+// attestation conveyance preference of the relying party.
+func (a *Authenticator) WithAttestation(format AttestationFormat) *Authenticator {
+	a.attestation = format
+	return a
+}
+
+// WithAttachment sets the attachment modality of the Authenticator. Ceremonies requesting a different attachment
+// modality fail. Per default, the Authenticator accepts any attachment modality.
+func (a *Authenticator) WithAttachment(attachment webauthn.AuthenticatorAttachment) *Authenticator {
+	a.attachment = attachment
+	return a
+}
+
+// WithSignCount sets the initial value of the signature counter of new credentials and the increment applied on every
+// authentication. An increment of 0 lets the counter of a credential remain at its initial value, as is the case for
+// authenticators that do not support a signature counter.
+func (a *Authenticator) WithSignCount(initial uint32, increment uint32) *Authenticator {
+	a.signCount = initial
+	a.signCountIncrement = increment
+	return a
+}
+
+// WithUserPresence sets whether the user present (UP) flag is set in the authenticator data.
+func (a *Authenticator) WithUserPresence(userPresent bool) *Authenticator {
+	a.userPresent = userPresent
+	return a
+}
+
+// WithUserVerification sets whether the Authenticator verifies the user, i.e. whether the user verified (UV) flag is
+// set in the authenticator data. Ceremonies requiring user verification fail if set to false.
+func (a *Authenticator) WithUserVerification(userVerified bool) *Authenticator {
+	a.userVerified = userVerified
+	return a
+}
+
+// WithResidentKeys sets whether the Authenticator supports resident keys. Registrations requiring a resident key fail
+// if set to false; registrations preferring one create a non-discoverable credential.
+func (a *Authenticator) WithResidentKeys(supported bool) *Authenticator {
+	a.residentKeys = supported
+	return a
+}
+
+// Credentials returns the credentials held by the Authenticator in the order they have been registered.
+func (a *Authenticator) Credentials() []VirtualCredential {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	credentials := make([]VirtualCredential, len(a.credentials))
+	for i, c := range a.credentials {
+		credentials[i] = *c
+	}
+	return credentials
+}
+
+// Register creates a new credential using the given options and returns the RegistrationFinalizationRequest to be
+// passed to webauthn.Client.FinalizeRegistration.
+func (a *Authenticator) Register(response *webauthn.RegistrationInitializationResponse) (*webauthn.RegistrationFinalizationRequest, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	options := response.Response
+
+	if !a.supportsAlgorithm(options.Parameters) {
+		return nil, fmt.Errorf("%w: none of the requested algorithms is supported", ErrNotSupported)
+	}
+	if err := a.checkAttachment(webauthn.AuthenticatorAttachment(options.AuthenticatorSelection.AuthenticatorAttachment)); err != nil {
+		return nil, err
+	}
+	if err := a.checkUserVerification(options.AuthenticatorSelection.UserVerification); err != nil {
+		return nil, err
+	}
+	residentKey := response.ResidentKey == webauthn.ResidentKeyRequired ||
+		options.AuthenticatorSelection.RequireResidentKey != nil && *options.AuthenticatorSelection.RequireResidentKey
+	if residentKey && !a.residentKeys {
+		return nil, fmt.Errorf("%w: resident keys", ErrNotSupported)
+	}
+	if response.ResidentKey == webauthn.ResidentKeyPreferred && a.residentKeys {
+		residentKey = true
+	}
+	for _, excluded := range options.CredentialExcludeList {
+		if a.findCredential(options.RelyingParty.ID, excluded.CredentialID) != nil {
+			return nil, ErrCredentialExcluded
+		}
+	}
+
+	privateKey, publicKey, err := generateKey(a.algorithm)
+	if err != nil {
+		return nil, err
+	}
+	rawId := make([]byte, 32)
+	if _, err = rand.Read(rawId); err != nil {
+		return nil, err
+	}
+	prfSecret := make([]byte, 32)
+	if _, err = rand.Read(prfSecret); err != nil {
+		return nil, err
+	}
+	c := &VirtualCredential{
+		ID:           base64.RawURLEncoding.EncodeToString(rawId),
+		RelyingParty: options.RelyingParty.ID,
+		UserHandle:   options.User.ID,
+		ResidentKey:  residentKey,
+		SignCount:    a.signCount,
+		rawId:        rawId,
+		algorithm:    a.algorithm,
+		privateKey:   privateKey,
+		prfSecret:    prfSecret,
+	}
+
+	clientDataJSON, err := a.clientDataJSON(protocol.CreateCeremony, options.Challenge)
+	if err != nil {
+		return nil, err
+	}
+	attestedCredentialData := &bytes.Buffer{}
+	attestedCredentialData.Write(a.aaguid[:])
+	_ = binary.Write(attestedCredentialData, binary.BigEndian, uint16(len(rawId)))
+	attestedCredentialData.Write(rawId)
+	attestedCredentialData.Write(publicKey)
+	authData := a.authenticatorData(c, protocol.FlagAttestedCredentialData, attestedCredentialData.Bytes())
+
+	attStatement := map[string]interface{}{}
+	if a.attestation == AttestationPacked {
+		clientDataHash := sha256.Sum256(clientDataJSON)
+		signature, err := c.sign(append(append([]byte{}, authData...), clientDataHash[:]...))
+		if err != nil {
+			return nil, err
+		}
+		attStatement["alg"] = int64(c.algorithm)
+		attStatement["sig"] = signature
+	}
+	attestationObject, err := cbor.Marshal(&struct {
+		Format       AttestationFormat      `cbor:"fmt"`
+		AttStatement map[string]interface{} `cbor:"attStmt"`
+		AuthData     []byte                 `cbor:"authData"`
+	}{a.attestation, attStatement, authData})
+	if err != nil {
+		return nil, err
+	}
+
+	a.credentials = append(a.credentials, c)
+	request := &webauthn.RegistrationFinalizationRequest{}
+	request.PublicKeyCredential = c.publicKeyCredential()
+	request.AttestationResponse = protocol.AuthenticatorAttestationResponse{
+		AuthenticatorResponse: protocol.AuthenticatorResponse{ClientDataJSON: clientDataJSON},
+		AttestationObject:     attestationObject,
+	}
+	request.ClientExtensionResults = registrationOutputs(options.Extensions, c)
+	return request, nil
+}
+
+// Authenticate creates an assertion using the given options and returns the AuthenticationFinalizationRequest to be
+// passed to webauthn.Client.FinalizeAuthentication. If the options do not list allowed credentials, the first
+// resident credential registered for the relying party is used.
+func (a *Authenticator) Authenticate(response *webauthn.AuthenticationInitializationResponse) (*webauthn.AuthenticationFinalizationRequest, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	options := response.Response
+
+	if err := a.checkUserVerification(options.UserVerification); err != nil {
+		return nil, err
+	}
+	var c *VirtualCredential
+	for _, allowed := range options.AllowedCredentials {
+		if c = a.findCredential(options.RelyingPartyID, allowed.CredentialID); c != nil {
+			break
+		}
+	}
+	if len(options.AllowedCredentials) == 0 {
+		for _, resident := range a.credentials {
+			if resident.ResidentKey && resident.RelyingParty == options.RelyingPartyID {
+				c = resident
+				break
+			}
+		}
+	}
+	if c == nil {
+		return nil, ErrNoCredential
+	}
+
+	clientDataJSON, err := a.clientDataJSON(protocol.AssertCeremony, options.Challenge)
+	if err != nil {
+		return nil, err
+	}
+	c.SignCount += a.signCountIncrement
+	authData := a.authenticatorData(c, 0, nil)
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	signature, err := c.sign(append(append([]byte{}, authData...), clientDataHash[:]...))
+	if err != nil {
+		return nil, err
+	}
+
+	request := &webauthn.AuthenticationFinalizationRequest{}
+	request.PublicKeyCredential = c.publicKeyCredential()
+	request.AssertionResponse = protocol.AuthenticatorAssertionResponse{
+		AuthenticatorResponse: protocol.AuthenticatorResponse{ClientDataJSON: clientDataJSON},
+		AuthenticatorData:     authData,
+		Signature:             signature,
+	}
+	if c.ResidentKey {
+		request.AssertionResponse.UserHandle = c.UserHandle
+	}
+	request.ClientExtensionResults = authenticationOutputs(options.Extensions, c)
+	return request, nil
+}
+
+// AuthenticateTransaction is like Authenticate but confirms a transaction and returns the
+// TransactionFinalizationRequest to be passed to webauthn.Client.FinalizeTransaction.
+func (a *Authenticator) AuthenticateTransaction(response *webauthn.TransactionInitializationResponse) (*webauthn.TransactionFinalizationRequest, error) {
+	request, err := a.Authenticate(&response.AuthenticationInitializationResponse)
+	if err != nil {
+		return nil, err
+	}
+	return &webauthn.TransactionFinalizationRequest{AuthenticationFinalizationRequest: *request}, nil
+}
+
+// supportsAlgorithm reports whether the algorithm of the Authenticator is among the given parameters. An empty list of
+// parameters allows any algorithm.
+func (a *Authenticator) supportsAlgorithm(parameters []protocol.CredentialParameter) bool {
+	for _, parameter := range parameters {
+		if parameter.Algorithm == a.algorithm {
+			return true
+		}
+	}
+	return len(parameters) == 0
+}
+
+// checkAttachment returns an error if the requested attachment modality does not match the Authenticator.
+func (a *Authenticator) checkAttachment(attachment webauthn.AuthenticatorAttachment) error {
+	if attachment != "" && a.attachment != "" && attachment != a.attachment {
+		return fmt.Errorf("%w: attachment '%s'", ErrNotSupported, attachment)
+	}
+	return nil
+}
+
+// checkUserVerification returns an error if user verification is required but not performed by the Authenticator.
+func (a *Authenticator) checkUserVerification(requirement protocol.UserVerificationRequirement) error {
+	if requirement == protocol.VerificationRequired && !a.userVerified {
+		return fmt.Errorf("%w: user verification", ErrNotSupported)
+	}
+	return nil
+}
+
+// findCredential returns the credential with the given ID registered for the given relying party, nil if there is none.
+func (a *Authenticator) findCredential(relyingParty string, rawId []byte) *VirtualCredential {
+	for _, c := range a.credentials {
+		if c.RelyingParty == relyingParty && bytes.Equal(c.rawId, rawId) {
+			return c
+		}
+	}
+	return nil
+}
+
+// clientDataJSON creates the JSON serialized client data of a ceremony.
+func (a *Authenticator) clientDataJSON(ceremony protocol.CeremonyType, challenge protocol.Challenge) ([]byte, error) {
+	return json.Marshal(&protocol.CollectedClientData{
+		Type:      ceremony,
+		Challenge: challenge.String(),
+		Origin:    a.origin,
+	})
+}
+
+// authenticatorData creates the authenticator data for the given credential with the given additional flags and data.
+func (a *Authenticator) authenticatorData(c *VirtualCredential, flags protocol.AuthenticatorFlags, data []byte) []byte {
+	if a.userPresent {
+		flags |= protocol.FlagUserPresent
+	}
+	if a.userVerified {
+		flags |= protocol.FlagUserVerified
+	}
+	rpIdHash := sha256.Sum256([]byte(c.RelyingParty))
+	authData := &bytes.Buffer{}
+	authData.Write(rpIdHash[:])
+	authData.WriteByte(byte(flags))
+	_ = binary.Write(authData, binary.BigEndian, c.SignCount)
+	authData.Write(data)
+	return authData.Bytes()
+}
+
+// publicKeyCredential returns the protocol.PublicKeyCredential representation of the credential.
+func (c *VirtualCredential) publicKeyCredential() protocol.PublicKeyCredential {
+	return protocol.PublicKeyCredential{
+		Credential: protocol.Credential{ID: c.ID, Type: string(protocol.PublicKeyCredentialType)},
+		RawID:      c.rawId,
+	}
+}
+
+// sign signs the given data using the private key of the credential.
+func (c *VirtualCredential) sign(data []byte) ([]byte, error) {
+	if c.algorithm == webauthncose.AlgEdDSA {
+		return c.privateKey.Sign(rand.Reader, data, crypto.Hash(0))
+	}
+	digest := sha256.Sum256(data)
+	return c.privateKey.Sign(rand.Reader, digest[:], crypto.SHA256)
+}
+
+// generateKey generates a key pair for the given algorithm and returns the private key and the CBOR encoded COSE
+// representation of the public key.
+func generateKey(algorithm webauthncose.COSEAlgorithmIdentifier) (crypto.Signer, []byte, error) {
+	switch algorithm {
+	case webauthncose.AlgES256:
+		privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return nil, nil, err
+		}
+		publicKey, err := cbor.Marshal(map[int]interface{}{
+			1:  int(webauthncose.EllipticKey),
+			3:  int(algorithm),
+			-1: 1, // P-256
+			-2: privateKey.X.FillBytes(make([]byte, 32)),
+			-3: privateKey.Y.FillBytes(make([]byte, 32)),
+		})
+		return privateKey, publicKey, err
+	case webauthncose.AlgRS256:
+		privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			return nil, nil, err
+		}
+		publicKey, err := cbor.Marshal(map[int]interface{}{
+			1:  int(webauthncose.RSAKey),
+			3:  int(algorithm),
+			-1: privateKey.N.Bytes(),
+			-2: big.NewInt(int64(privateKey.E)).FillBytes(make([]byte, 3)),
+		})
+		return privateKey, publicKey, err
+	case webauthncose.AlgEdDSA:
+		public, privateKey, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, nil, err
+		}
+		publicKey, err := cbor.Marshal(map[int]interface{}{
+			1:  int(webauthncose.OctetKey),
+			3:  int(algorithm),
+			-1: 6, // Ed25519
+			-2: []byte(public),
+		})
+		return privateKey, publicKey, err
+	}
+	return nil, nil, fmt.Errorf("%w: algorithm %d", ErrNotSupported, algorithm)
+}
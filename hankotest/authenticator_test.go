@@ -0,0 +1,424 @@
+package hankotest
+
+import (
+	"bytes"
+	"errors"
+	"github.com/google/uuid"
+	hankoClient "github.com/teamhanko/hanko-go/client"
+	"github.com/teamhanko/hanko-go/webauthn"
+	"github.com/teamhanko/webauthn/protocol/webauthncose"
+	"testing"
+)
+
+func register(t *testing.T, client *webauthn.Client, authenticator *Authenticator, request *webauthn.RegistrationInitializationRequest) (*webauthn.Credential, error) {
+	t.Helper()
+	initialization, apiErr := client.InitializeRegistration(request)
+	if apiErr != nil {
+		t.Fatal(apiErr)
+	}
+	finalization, err := authenticator.Register(initialization)
+	if err != nil {
+		return nil, err
+	}
+	response, apiErr := client.FinalizeRegistration(finalization)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	return &response.Credential, nil
+}
+
+func authenticate(t *testing.T, client *webauthn.Client, authenticator *Authenticator, request *webauthn.AuthenticationInitializationRequest) (*webauthn.Credential, error) {
+	t.Helper()
+	initialization, apiErr := client.InitializeAuthentication(request)
+	if apiErr != nil {
+		t.Fatal(apiErr)
+	}
+	finalization, err := authenticator.Authenticate(initialization)
+	if err != nil {
+		return nil, err
+	}
+	response, apiErr := client.FinalizeAuthentication(finalization)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	return &response.Credential, nil
+}
+
+func newRegistrationRequest(userId string) *webauthn.RegistrationInitializationRequest {
+	return webauthn.NewRegistrationInitializationRequest(webauthn.NewRegistrationInitializationUser(userId, userId+"@example.com"))
+}
+
+func newAuthenticationRequest(userId string) *webauthn.AuthenticationInitializationRequest {
+	return webauthn.NewAuthenticationInitializationRequest().WithUser(webauthn.NewAuthenticationInitializationUser(userId))
+}
+
+func TestAuthenticator_RegisterAndAuthenticate(t *testing.T) {
+	var tests = []struct {
+		name        string
+		algorithm   webauthncose.COSEAlgorithmIdentifier
+		attestation AttestationFormat
+	}{
+		{name: "ES256 none", algorithm: webauthncose.AlgES256, attestation: AttestationNone},
+		{name: "ES256 packed", algorithm: webauthncose.AlgES256, attestation: AttestationPacked},
+		{name: "RS256 none", algorithm: webauthncose.AlgRS256, attestation: AttestationNone},
+		{name: "RS256 packed", algorithm: webauthncose.AlgRS256, attestation: AttestationPacked},
+		{name: "EdDSA none", algorithm: webauthncose.AlgEdDSA, attestation: AttestationNone},
+		{name: "EdDSA packed", algorithm: webauthncose.AlgEdDSA, attestation: AttestationPacked},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := NewServer(testApiSecret).WithHmac(testApiKeyId)
+			defer server.Close()
+			client := webauthn.NewClient(server.URL, testApiSecret).WithHmac(testApiKeyId).WithoutLogs()
+			aaguid := uuid.New()
+			authenticator := NewAuthenticator().WithAlgorithm(tt.algorithm).WithAttestation(tt.attestation).WithAaguid(aaguid)
+
+			registered, err := register(t, client, authenticator, newRegistrationRequest("user"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if registered.User.ID != "user" || !registered.UserVerification || registered.Authenticator.Aaguid != aaguid.String() {
+				t.Errorf("got %+v, want a user verified credential of the user", registered)
+			}
+
+			for i := 0; i < 2; i++ {
+				authenticated, err := authenticate(t, client, authenticator, newAuthenticationRequest("user"))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if authenticated.Id != registered.Id {
+					t.Errorf("got credential %s, want %s", authenticated.Id, registered.Id)
+				}
+			}
+			if authenticator.Credentials()[0].SignCount != 2 {
+				t.Errorf("got sign count %d, want 2", authenticator.Credentials()[0].SignCount)
+			}
+
+			transaction := webauthn.NewTransactionInitializationRequest(webauthn.NewAuthenticationInitializationUser("user")).
+				WithTransaction("transfer 100 EUR")
+			initialization, apiErr := client.InitializeTransaction(transaction)
+			if apiErr != nil {
+				t.Fatal(apiErr)
+			}
+			finalization, err := authenticator.AuthenticateTransaction(initialization)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, apiErr = client.FinalizeTransaction(finalization); apiErr != nil {
+				t.Error(apiErr)
+			}
+		})
+	}
+}
+
+func TestAuthenticator_ResidentKey(t *testing.T) {
+	server := NewServer(testApiSecret)
+	defer server.Close()
+	client := webauthn.NewClient(server.URL, testApiSecret).WithoutLogs()
+	authenticator := NewAuthenticator()
+
+	request := newRegistrationRequest("user").
+		WithAuthenticatorSelection(webauthn.NewAuthenticatorSelection().WithRequireResidentKey(true))
+	registered, err := register(t, client, authenticator, request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !registered.IsResidentKey {
+		t.Error("expected a resident credential")
+	}
+
+	authenticated, err := authenticate(t, client, authenticator, webauthn.NewAuthenticationInitializationRequest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if authenticated.User.ID != "user" {
+		t.Errorf("got user %q, want the user of the resident credential", authenticated.User.ID)
+	}
+
+	_, err = register(t, client, NewAuthenticator().WithResidentKeys(false), request)
+	if !errors.Is(err, ErrNotSupported) {
+		t.Errorf("got %v, want an error for an authenticator without resident keys", err)
+	}
+	_, err = authenticate(t, client, NewAuthenticator(), webauthn.NewAuthenticationInitializationRequest())
+	if !errors.Is(err, ErrNoCredential) {
+		t.Errorf("got %v, want an error for an authenticator without credentials", err)
+	}
+}
+
+func TestAuthenticator_PreferredResidentKey(t *testing.T) {
+	server := NewServer(testApiSecret)
+	defer server.Close()
+	client := webauthn.NewClient(server.URL, testApiSecret).WithoutLogs()
+
+	var tests = []struct {
+		name         string
+		residentKeys bool
+		expected     bool
+	}{
+		{name: "supported", residentKeys: true, expected: true},
+		{name: "not supported", residentKeys: false, expected: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authenticator := NewAuthenticator().WithResidentKeys(tt.residentKeys)
+			initialization, apiErr := client.InitializeRegistration(newRegistrationRequest("user").
+				WithResidentKey(webauthn.ResidentKeyPreferred).
+				WithExtensions(webauthn.NewRegistrationExtensionInputs().WithCredProps()))
+			if apiErr != nil {
+				t.Fatal(apiErr)
+			}
+			registration, err := authenticator.Register(initialization)
+			if err != nil {
+				t.Fatal(err)
+			}
+			registered, apiErr := client.FinalizeRegistration(registration)
+			if apiErr != nil {
+				t.Fatal(apiErr)
+			}
+			if resident := authenticator.Credentials()[0].ResidentKey; resident != tt.expected {
+				t.Errorf("got resident key %t, want %t", resident, tt.expected)
+			}
+			if registered.IsDiscoverable() != tt.expected {
+				t.Errorf("got discoverable %t, want the credProps output to be reported", registered.IsDiscoverable())
+			}
+			if registered.Credential.IsResidentKey {
+				t.Error("expected the server not to trust the credProps output of the client")
+			}
+		})
+	}
+}
+
+func TestAuthenticator_Extensions(t *testing.T) {
+	server := NewServer(testApiSecret)
+	defer server.Close()
+	client := webauthn.NewClient(server.URL, testApiSecret).WithoutLogs()
+	authenticator := NewAuthenticator().WithResidentKeys(false)
+
+	initialization, apiErr := client.InitializeRegistration(newRegistrationRequest("user").
+		WithResidentKey(webauthn.ResidentKeyPreferred).
+		WithExtensions(webauthn.NewRegistrationExtensionInputs().WithCredProps().WithPRF(nil)))
+	if apiErr != nil {
+		t.Fatal(apiErr)
+	}
+	registration, err := authenticator.Register(initialization)
+	if err != nil {
+		t.Fatal(err)
+	}
+	registered, apiErr := client.FinalizeRegistration(registration)
+	if apiErr != nil {
+		t.Fatal(apiErr)
+	}
+	outputs := registered.ClientExtensionResults
+	if outputs == nil || outputs.CredProps == nil || *outputs.CredProps.ResidentKey || !*outputs.PRF.Enabled {
+		t.Errorf("got %+v, want a non-discoverable credential with prf enabled", outputs)
+	}
+	if registered.IsDiscoverable() {
+		t.Error("expected a non-discoverable credential")
+	}
+
+	salt := webauthn.PRFValues{First: []byte("encryption key")}
+	var results [][]byte
+	for i := 0; i < 2; i++ {
+		request := webauthn.NewAuthenticationInitializationRequest().
+			WithUser(webauthn.NewAuthenticationInitializationUser("user")).
+			WithExtensions(webauthn.NewAuthenticationExtensionInputs().WithPRF(salt).WithAppID("https://example.com/app-id.json"))
+		authenticated, err := authenticateWithOutputs(t, client, authenticator, request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if authenticated.AppID == nil || *authenticated.AppID || authenticated.PRF == nil || len(authenticated.PRF.Results.First) != 32 {
+			t.Fatalf("got %+v, want the appid and prf outputs", authenticated)
+		}
+		results = append(results, authenticated.PRF.Results.First)
+	}
+	if !bytes.Equal(results[0], results[1]) {
+		t.Error("expected the prf results for the same input to be equal")
+	}
+
+	request := webauthn.NewAuthenticationInitializationRequest().
+		WithUser(webauthn.NewAuthenticationInitializationUser("user")).
+		WithExtensions(webauthn.NewAuthenticationExtensionInputs().WithPRF(salt).
+			WithPRFByCredential(registered.Credential.Id, webauthn.PRFValues{First: []byte("other key")}))
+	authenticated, err := authenticateWithOutputs(t, client, authenticator, request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(authenticated.PRF.Results.First, results[0]) {
+		t.Error("expected the inputs given for the credential to be used")
+	}
+}
+
+// authenticateWithOutputs authenticates using the given request and returns the client extension outputs.
+func authenticateWithOutputs(t *testing.T, client *webauthn.Client, authenticator *Authenticator, request *webauthn.AuthenticationInitializationRequest) (*webauthn.ClientExtensionOutputs, error) {
+	t.Helper()
+	initialization, apiErr := client.InitializeAuthentication(request)
+	if apiErr != nil {
+		t.Fatal(apiErr)
+	}
+	finalization, err := authenticator.Authenticate(initialization)
+	if err != nil {
+		return nil, err
+	}
+	response, apiErr := client.FinalizeAuthentication(finalization)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	return response.ClientExtensionResults, nil
+}
+
+func TestAuthenticator_ConditionalMediation(t *testing.T) {
+	server := NewServer(testApiSecret)
+	defer server.Close()
+	client := webauthn.NewClient(server.URL, testApiSecret).WithoutLogs()
+	authenticator := NewAuthenticator()
+
+	if _, err := register(t, client, authenticator, newRegistrationRequest("other")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := register(t, client, authenticator, newRegistrationRequest("user").WithResidentKey(webauthn.ResidentKeyRequired)); err != nil {
+		t.Fatal(err)
+	}
+
+	initialization, apiErr := client.InitializeAuthentication(webauthn.NewAuthenticationInitializationRequest().WithConditionalMediation())
+	if apiErr != nil {
+		t.Fatal(apiErr)
+	}
+	if initialization.Mediation != webauthn.MediationConditional || len(initialization.Response.AllowedCredentials) != 0 {
+		t.Errorf("got %+v, want conditional mediation without allowed credentials", initialization)
+	}
+	finalization, err := authenticator.Authenticate(initialization)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response, apiErr := client.FinalizeAuthentication(finalization)
+	if apiErr != nil {
+		t.Fatal(apiErr)
+	}
+	if !response.Credential.IsResidentKey || response.Credential.User.ID != "user" {
+		t.Errorf("got %+v, want the discoverable credential of the user", response.Credential)
+	}
+}
+
+func TestAuthenticator_ExcludeAndAllowCredentials(t *testing.T) {
+	server := NewServer(testApiSecret)
+	defer server.Close()
+	client := webauthn.NewClient(server.URL, testApiSecret).WithoutLogs()
+	first, second := NewAuthenticator(), NewAuthenticator()
+
+	registered, err := register(t, client, first, newRegistrationRequest("user"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	descriptors, err := client.ListCredentialDescriptors("user")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = register(t, client, first, newRegistrationRequest("user").WithExcludeCredentials(descriptors...)); !errors.Is(err, ErrCredentialExcluded) {
+		t.Errorf("got %v, want %v", err, ErrCredentialExcluded)
+	}
+	if _, err = register(t, client, second, newRegistrationRequest("user").WithExcludeCredentials(descriptors...)); err != nil {
+		t.Fatal(err)
+	}
+
+	allowFirst := newAuthenticationRequest("user").WithAllowCredentials(webauthn.NewCredentialDescriptor(registered.Id))
+	if _, err = authenticate(t, client, second, allowFirst); !errors.Is(err, ErrNoCredential) {
+		t.Errorf("got %v, want %v", err, ErrNoCredential)
+	}
+	authenticated, err := authenticate(t, client, first, allowFirst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if authenticated.Id != registered.Id {
+		t.Errorf("got credential %s, want %s", authenticated.Id, registered.Id)
+	}
+}
+
+func TestAuthenticator_Errors(t *testing.T) {
+	server := NewServer(testApiSecret)
+	defer server.Close()
+	client := webauthn.NewClient(server.URL, testApiSecret).WithoutLogs()
+	required := webauthn.NewAuthenticatorSelection().WithUserVerification(webauthn.VerificationRequired)
+
+	var tests = []struct {
+		name          string
+		authenticator *Authenticator
+		request       *webauthn.RegistrationInitializationRequest
+		expectedError error
+	}{
+		{
+			name:          "user verification required",
+			authenticator: NewAuthenticator().WithUserVerification(false),
+			request:       newRegistrationRequest("uv").WithAuthenticatorSelection(required),
+			expectedError: ErrNotSupported,
+		},
+		{
+			name:          "attachment mismatch",
+			authenticator: NewAuthenticator().WithAttachment(webauthn.CrossPlatform),
+			request:       newRegistrationRequest("attachment").WithAuthenticatorSelection(webauthn.NewAuthenticatorSelection().WithAuthenticatorAttachment(webauthn.Platform)),
+			expectedError: ErrNotSupported,
+		},
+		{
+			name:          "unsupported algorithm",
+			authenticator: NewAuthenticator().WithAlgorithm(webauthncose.AlgPS256),
+			request:       newRegistrationRequest("algorithm"),
+			expectedError: ErrNotSupported,
+		},
+		{
+			name:          "wrong origin",
+			authenticator: NewAuthenticator().WithOrigin("https://evil.example.com"),
+			request:       newRegistrationRequest("origin"),
+			expectedError: hankoClient.ErrValidation,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := register(t, client, tt.authenticator, tt.request)
+			if !errors.Is(err, tt.expectedError) {
+				t.Errorf("got %v, want %v", err, tt.expectedError)
+			}
+		})
+	}
+
+	authenticator := NewAuthenticator()
+	if _, err := register(t, client, authenticator, newRegistrationRequest("excluded")); err != nil {
+		t.Fatal(err)
+	}
+	_, err := register(t, client, authenticator, newRegistrationRequest("excluded"))
+	if !errors.Is(err, ErrCredentialExcluded) {
+		t.Errorf("got %v, want an error for an excluded credential", err)
+	}
+}
+
+func TestAuthenticator_SignCount(t *testing.T) {
+	server := NewServer(testApiSecret)
+	defer server.Close()
+	client := webauthn.NewClient(server.URL, testApiSecret).WithoutLogs()
+
+	withoutCounter := NewAuthenticator().WithSignCount(0, 0)
+	if _, err := register(t, client, withoutCounter, newRegistrationRequest("without counter")); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := authenticate(t, client, withoutCounter, newAuthenticationRequest("without counter")); err != nil {
+			t.Errorf("got %v, want authenticators without counter to be accepted", err)
+		}
+	}
+
+	cloned := NewAuthenticator().WithSignCount(5, 0)
+	if _, err := register(t, client, cloned, newRegistrationRequest("cloned")); err != nil {
+		t.Fatal(err)
+	}
+	_, err := authenticate(t, client, cloned, newAuthenticationRequest("cloned"))
+	if !hankoClient.IsValidationError(err) {
+		t.Errorf("got %v, want an error for a sign counter that did not increase", err)
+	}
+
+	withoutVerification := NewAuthenticator().WithUserVerification(false)
+	registered, err := register(t, client, withoutVerification, newRegistrationRequest("without verification"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if registered.UserVerification {
+		t.Error("expected a credential registered without user verification")
+	}
+}
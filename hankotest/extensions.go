@@ -0,0 +1,104 @@
+package hankotest
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/json"
+	"github.com/teamhanko/hanko-go/webauthn"
+	"github.com/teamhanko/webauthn/protocol"
+)
+
+// prfSaltContext is prepended to the inputs of the prf extension before hashing them into salts.
+const prfSaltContext = "WebAuthn PRF\x00"
+
+// encodeExtensions converts typed extension inputs into the extensions of the creation or request options. It returns
+// nil if there are no inputs.
+func encodeExtensions(inputs interface{}) protocol.AuthenticationExtensions {
+	data, err := json.Marshal(inputs)
+	if err != nil {
+		return nil
+	}
+	extensions := protocol.AuthenticationExtensions{}
+	if err = json.Unmarshal(data, &extensions); err != nil || len(extensions) == 0 {
+		return nil
+	}
+	return extensions
+}
+
+// decodeExtensions converts the extensions of the creation or request options into the given typed extension inputs.
+func decodeExtensions(extensions protocol.AuthenticationExtensions, inputs interface{}) {
+	if len(extensions) == 0 {
+		return
+	}
+	if data, err := json.Marshal(extensions); err == nil {
+		_ = json.Unmarshal(data, inputs)
+	}
+}
+
+// registrationOutputs returns the client extension outputs of a registration creating the given credential. Only
+// credProps and prf are supported; large blobs are reported as not supported.
+func registrationOutputs(extensions protocol.AuthenticationExtensions, c *VirtualCredential) *webauthn.ClientExtensionOutputs {
+	inputs := webauthn.RegistrationExtensionInputs{}
+	decodeExtensions(extensions, &inputs)
+	outputs := &webauthn.ClientExtensionOutputs{}
+	if inputs.CredProps {
+		residentKey := c.ResidentKey
+		outputs.CredProps = &webauthn.CredentialPropertiesOutput{ResidentKey: &residentKey}
+	}
+	if inputs.LargeBlob != nil {
+		supported := false
+		outputs.LargeBlob = &webauthn.LargeBlobOutputs{Supported: &supported}
+	}
+	if inputs.PRF != nil {
+		enabled := true
+		outputs.PRF = &webauthn.PRFOutputs{Enabled: &enabled}
+		if inputs.PRF.Eval != nil {
+			outputs.PRF.Results = c.evaluatePRF(*inputs.PRF.Eval)
+		}
+	}
+	if *outputs == (webauthn.ClientExtensionOutputs{}) {
+		return nil
+	}
+	return outputs
+}
+
+// authenticationOutputs returns the client extension outputs of an authentication using the given credential. The
+// appid extension is reported as not used, large blobs are not supported.
+func authenticationOutputs(extensions protocol.AuthenticationExtensions, c *VirtualCredential) *webauthn.ClientExtensionOutputs {
+	inputs := webauthn.AuthenticationExtensionInputs{}
+	decodeExtensions(extensions, &inputs)
+	outputs := &webauthn.ClientExtensionOutputs{}
+	if inputs.AppID != "" {
+		used := false
+		outputs.AppID = &used
+	}
+	if inputs.PRF != nil {
+		eval := inputs.PRF.Eval
+		if values, ok := inputs.PRF.EvalByCredential[c.ID]; ok {
+			eval = &values
+		}
+		if eval != nil {
+			outputs.PRF = &webauthn.PRFOutputs{Results: c.evaluatePRF(*eval)}
+		}
+	}
+	if *outputs == (webauthn.ClientExtensionOutputs{}) {
+		return nil
+	}
+	return outputs
+}
+
+// evaluatePRF evaluates the pseudo-random function of the credential, HMAC-SHA-256 with a credential specific secret
+// like the hmac-secret authenticator extension, for the given inputs.
+func (c *VirtualCredential) evaluatePRF(values webauthn.PRFValues) *webauthn.PRFValues {
+	evaluate := func(input []byte) protocol.URLEncodedBase64 {
+		salt := sha256.Sum256(append([]byte(prfSaltContext), input...))
+		mac := hmac.New(sha256.New, c.prfSecret)
+		mac.Write(salt[:])
+		return mac.Sum(nil)
+	}
+	results := &webauthn.PRFValues{First: evaluate(values.First)}
+	if values.Second != nil {
+		results.Second = evaluate(values.Second)
+	}
+	return results
+}
@@ -0,0 +1,201 @@
+package hankotest
+
+import (
+	"fmt"
+	"github.com/google/uuid"
+	hankoClient "github.com/teamhanko/hanko-go/client"
+	"github.com/teamhanko/hanko-go/passlink"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// link is an initialized Passlink.
+type link struct {
+	passlink.Link
+	request passlink.LinkRequest
+}
+
+// Passlink returns the Passlink with the given ID and whether it exists.
+func (s *Server) Passlink(id uuid.UUID) (passlink.Link, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	l, ok := s.passlinks[id]
+	if !ok {
+		return passlink.Link{}, false
+	}
+	return l.Link, true
+}
+
+// PasslinkRequest returns the LinkRequest the Passlink with the given ID has been initialized with, e.g. to inspect
+// the email address the Passlink would have been sent to.
+func (s *Server) PasslinkRequest(id uuid.UUID) (passlink.LinkRequest, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	l, ok := s.passlinks[id]
+	if !ok {
+		return passlink.LinkRequest{}, false
+	}
+	return l.request, true
+}
+
+// ConfirmPasslink confirms the Passlink with the given ID as if the user had clicked the link. Alternatively, send a GET
+// request to "{URL}/v1/passlink/{id}/confirm" to be redirected to the RedirectTo URL of the Passlink.
+func (s *Server) ConfirmPasslink(id uuid.UUID) (passlink.Link, *hankoClient.ApiError) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	l, apiErr := s.confirmPasslink(id)
+	if apiErr != nil {
+		return passlink.Link{}, apiErr
+	}
+	return l.Link, nil
+}
+
+// ExpirePasslink lets the Passlink with the given ID expire, e.g. to test the handling of expired Passlinks.
+func (s *Server) ExpirePasslink(id uuid.UUID) *Server {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if l, ok := s.passlinks[id]; ok {
+		l.ValidUntil = time.Now().UTC().Add(-time.Second)
+	}
+	return s
+}
+
+// servePasslink dispatches a request to the Passlink endpoints.
+func (s *Server) servePasslink(w http.ResponseWriter, r *http.Request, segments []string, body []byte) {
+	switch {
+	case len(segments) == 1 && segments[0] == "initialize" && r.Method == http.MethodPost:
+		s.handleInitializePasslink(w, body)
+	case len(segments) == 2 && segments[1] == "finalize" && r.Method == http.MethodPatch:
+		s.handleFinalizePasslink(w, segments[0])
+	default:
+		writeErrorf(w, http.StatusNotFound, "not found")
+	}
+}
+
+// handleInitializePasslink creates a new pending Passlink.
+func (s *Server) handleInitializePasslink(w http.ResponseWriter, body []byte) {
+	request := passlink.LinkRequest{}
+	if err := decodeJSON(body, &request); err != nil {
+		writeErrorf(w, http.StatusBadRequest, "invalid request body", err.Error())
+		return
+	}
+	if request.UserID == "" || request.Email == "" {
+		writeErrorf(w, http.StatusBadRequest, "invalid request body", "user_id and email are required")
+		return
+	}
+	if request.Transport != "email" {
+		writeErrorf(w, http.StatusBadRequest, "invalid request body", fmt.Sprintf("unsupported transport '%s'", request.Transport))
+		return
+	}
+	ttl := defaultPasslinkTTL
+	if request.TTL != "" {
+		parsed, err := time.ParseDuration(request.TTL)
+		if err != nil || parsed <= 0 {
+			writeErrorf(w, http.StatusBadRequest, "invalid request body", fmt.Sprintf("invalid ttl '%s'", request.TTL))
+			return
+		}
+		ttl = parsed
+	}
+	if request.RedirectTo != "" {
+		if _, err := url.ParseRequestURI(request.RedirectTo); err != nil {
+			writeErrorf(w, http.StatusBadRequest, "invalid request body", fmt.Sprintf("invalid redirect_to '%s'", request.RedirectTo))
+			return
+		}
+	}
+
+	l := &link{
+		Link: passlink.Link{
+			ID:         uuid.New(),
+			UserID:     request.UserID,
+			Status:     passlink.StatusPending,
+			ValidUntil: time.Now().UTC().Add(ttl),
+		},
+		request: request,
+	}
+	s.passlinks[l.ID] = l
+	writeJSON(w, http.StatusOK, &l.Link)
+}
+
+// handleConfirmPasslink confirms a Passlink and redirects to its RedirectTo URL, appending the ID of the Passlink
+// using the LinkIdParameter. Responds with the confirmed Passlink if no RedirectTo URL has been set.
+func (s *Server) handleConfirmPasslink(w http.ResponseWriter, r *http.Request, linkId string) {
+	id, err := uuid.Parse(linkId)
+	if err != nil {
+		writeErrorf(w, http.StatusBadRequest, "invalid passlink id", err.Error())
+		return
+	}
+	l, apiErr := s.confirmPasslink(id)
+	if apiErr != nil {
+		writeError(w, apiErr)
+		return
+	}
+	if l.request.RedirectTo == "" {
+		writeJSON(w, http.StatusOK, &l.Link)
+		return
+	}
+
+	redirectTo, _ := url.Parse(l.request.RedirectTo)
+	query := redirectTo.Query()
+	query.Set(LinkIdParameter, l.ID.String())
+	redirectTo.RawQuery = query.Encode()
+	http.Redirect(w, r, redirectTo.String(), http.StatusSeeOther)
+}
+
+// handleFinalizePasslink finalizes a confirmed Passlink.
+func (s *Server) handleFinalizePasslink(w http.ResponseWriter, linkId string) {
+	id, err := uuid.Parse(linkId)
+	if err != nil {
+		writeErrorf(w, http.StatusBadRequest, "invalid passlink id", err.Error())
+		return
+	}
+	l, apiErr := s.validPasslink(id)
+	if apiErr != nil {
+		writeError(w, apiErr)
+		return
+	}
+	if l.Status != passlink.StatusConfirmed {
+		writeErrorf(w, http.StatusConflict, "passlink cannot be finalized", fmt.Sprintf("passlink is %s", l.Status))
+		return
+	}
+	l.Status = passlink.StatusFinished
+	writeJSON(w, http.StatusOK, &l.Link)
+}
+
+// confirmPasslink confirms the pending Passlink with the given ID.
+func (s *Server) confirmPasslink(id uuid.UUID) (*link, *hankoClient.ApiError) {
+	l, apiErr := s.validPasslink(id)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if l.Status != passlink.StatusPending {
+		return nil, &hankoClient.ApiError{
+			Message:    "passlink cannot be confirmed",
+			Details:    fmt.Sprintf("passlink is %s", l.Status),
+			StatusCode: http.StatusConflict,
+			StatusText: http.StatusText(http.StatusConflict),
+		}
+	}
+	l.Status = passlink.StatusConfirmed
+	return l, nil
+}
+
+// validPasslink returns the Passlink with the given ID if it exists and has not expired.
+func (s *Server) validPasslink(id uuid.UUID) (*link, *hankoClient.ApiError) {
+	l, ok := s.passlinks[id]
+	if !ok {
+		return nil, &hankoClient.ApiError{
+			Message:    "passlink not found",
+			StatusCode: http.StatusNotFound,
+			StatusText: http.StatusText(http.StatusNotFound),
+		}
+	}
+	if time.Now().After(l.ValidUntil) {
+		return nil, &hankoClient.ApiError{
+			Message:    "passlink expired",
+			StatusCode: http.StatusGone,
+			StatusText: http.StatusText(http.StatusGone),
+		}
+	}
+	return l, nil
+}
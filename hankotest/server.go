@@ -0,0 +1,354 @@
+// Package hankotest provides an in-process fake of the Hanko Authentication API for use in tests.
+//
+// A Server is a stateful fake of the WebAuthn and Passlink endpoints of the API. It keeps pending ceremonies,
+// registered credentials and Passlinks in memory, validates the authorization header of every request (using the API
+// secret or HMAC authorization) and records all requests it receives, e.g.:
+//
+//	server := hankotest.NewServer("secret").WithHmac("apiKeyId")
+//	defer server.Close()
+//
+//	client := webauthn.NewClient(server.URL, "secret").WithHmac("apiKeyId")
+//
+// Hooks allow injecting errors into the responses of the Server, e.g. to test the error handling of an application.
+package hankotest
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	hankoClient "github.com/teamhanko/hanko-go/client"
+	"github.com/teamhanko/webauthn/protocol"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultRelyingPartyID is the relying party ID used by a Server unless configured using Server.WithRelyingParty.
+	DefaultRelyingPartyID = "localhost"
+
+	// DefaultRelyingPartyName is the relying party name used by a Server unless configured using
+	// Server.WithRelyingParty.
+	DefaultRelyingPartyName = "Hanko Test"
+
+	// DefaultOrigin is the origin expected by a Server unless configured using Server.WithRelyingParty.
+	DefaultOrigin = "https://localhost"
+
+	// LinkIdParameter is the name of the query parameter that contains the ID of a confirmed Passlink when the Server
+	// redirects to the RedirectTo URL of the Passlink.
+	LinkIdParameter = "link_id"
+
+	apiVersion          = "v1"
+	hmacValidity        = 5 * time.Minute
+	ceremonyTimeout     = 5 * time.Minute
+	minCeremonyTimeout  = time.Second
+	maxCeremonyTimeout  = time.Hour
+	defaultPageSize     = 10
+	defaultPasslinkTTL  = 15 * time.Minute
+	authorizationSecret = "secret"
+	authorizationHmac   = "hanko"
+)
+
+// RecordedRequest is a request received by a Server.
+type RecordedRequest struct {
+	Method     string
+	Path       string
+	Query      url.Values
+	Header     http.Header
+	Body       []byte
+	StatusCode int // the status code of the response sent by the Server
+}
+
+// Hook is called for every request received by a Server before it is processed. Returning a non-nil ApiError aborts
+// the request and the Server responds with the given ApiError instead. The StatusCode of the ApiError defaults to 500.
+// Hooks are called without holding the lock of the Server, so they may call its methods, e.g. Requests or
+// AddCredential, but must be safe for concurrent use if the Server receives concurrent requests.
+type Hook func(request *RecordedRequest) *hankoClient.ApiError
+
+// Server is a stateful fake of the Hanko Authentication API. Create a Server using NewServer.
+type Server struct {
+	URL string // the base URL of the Server to be used when constructing clients
+
+	server       *httptest.Server
+	mu           sync.Mutex
+	secret       string
+	apiKeyId     string
+	relyingParty protocol.RelyingPartyEntity
+	origin       string
+	hooks        []Hook
+	requests     []*RecordedRequest
+	nonces       map[string]time.Time
+	ceremonies   map[string]*ceremony
+	credentials  []*credential
+	passlinks    map[uuid.UUID]*link
+}
+
+// NewServer starts and returns a new Server accepting requests authorized with the given API secret. The Server
+// should be closed using Server.Close when finished.
+func NewServer(secret string) *Server {
+	s := &Server{
+		secret: secret,
+		relyingParty: protocol.RelyingPartyEntity{
+			CredentialEntity: protocol.CredentialEntity{Name: DefaultRelyingPartyName},
+			ID:               DefaultRelyingPartyID,
+		},
+		origin:     DefaultOrigin,
+		nonces:     map[string]time.Time{},
+		ceremonies: map[string]*ceremony{},
+		passlinks:  map[uuid.UUID]*link{},
+	}
+	s.server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	s.URL = s.server.URL
+	return s
+}
+
+// Close shuts down the Server and blocks until all outstanding requests have completed.
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// WithHmac requires all requests to be authorized using HMAC authorization with the given apiKeyId. Requests authorized
+// using the plain API secret are rejected.
+func (s *Server) WithHmac(apiKeyId string) *Server {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.apiKeyId = apiKeyId
+	return s
+}
+
+// WithRelyingParty sets the relying party ID and name used in the options of a ceremony and the origin expected in the
+// client data of a finalization request.
+func (s *Server) WithRelyingParty(id string, name string, origin string) *Server {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.relyingParty.ID = id
+	s.relyingParty.Name = name
+	s.origin = origin
+	return s
+}
+
+// AddHook adds a Hook to be called for every request received by the Server. Hooks are called in the order they have
+// been added; the first Hook returning an ApiError aborts the request.
+func (s *Server) AddHook(hook Hook) *Server {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hooks = append(s.hooks, hook)
+	return s
+}
+
+// FailNext lets the next request with the given method and path (e.g. "/v1/webauthn/registration/initialize") fail
+// with the given ApiError. An empty method matches all methods.
+func (s *Server) FailNext(method string, path string, apiErr *hankoClient.ApiError) *Server {
+	var once sync.Once
+	return s.AddHook(func(request *RecordedRequest) (err *hankoClient.ApiError) {
+		if (method == "" || method == request.Method) && path == request.Path {
+			once.Do(func() {
+				err = apiErr
+			})
+		}
+		return err
+	})
+}
+
+// Requests returns all requests received by the Server in the order they have been received.
+func (s *Server) Requests() []RecordedRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	requests := make([]RecordedRequest, len(s.requests))
+	for i, request := range s.requests {
+		requests[i] = *request
+	}
+	return requests
+}
+
+// ResetRequests discards all requests recorded so far.
+func (s *Server) ResetRequests() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = nil
+}
+
+// serveHTTP records the request, calls the hooks, validates the authorization header and dispatches the request to
+// the handler of the requested endpoint.
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+	request := &RecordedRequest{
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Query:  r.URL.Query(),
+		Header: r.Header.Clone(),
+		Body:   body,
+	}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, request)
+	hooks := append([]Hook(nil), s.hooks...)
+	s.mu.Unlock()
+
+	// the hooks are called without holding the lock, so that they can inspect the Server
+	var hookErr *hankoClient.ApiError
+	for _, hook := range hooks {
+		if hookErr = hook(request); hookErr != nil {
+			break
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rw := &recordingResponseWriter{ResponseWriter: w, request: request}
+	if hookErr != nil {
+		writeError(rw, hookErr)
+		return
+	}
+
+	segments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(segments) < 3 || segments[0] != apiVersion {
+		writeErrorf(rw, http.StatusNotFound, "not found")
+		return
+	}
+
+	// the confirmation of a Passlink is requested by the user following the link, hence it is not authorized
+	if segments[1] == "passlink" && len(segments) == 4 && segments[3] == "confirm" {
+		s.handleConfirmPasslink(rw, r, segments[2])
+		return
+	}
+
+	if err := s.authorize(r, body); err != nil {
+		writeErrorf(rw, http.StatusUnauthorized, "unauthorized", err.Error())
+		return
+	}
+
+	switch segments[1] {
+	case "webauthn":
+		s.serveWebauthn(rw, r, segments[2:], body)
+	case "passlink":
+		s.servePasslink(rw, r, segments[2:], body)
+	default:
+		writeErrorf(rw, http.StatusNotFound, "not found")
+	}
+}
+
+// authorize validates the authorization header of the request.
+func (s *Server) authorize(r *http.Request, body []byte) error {
+	authorization := strings.SplitN(r.Header.Get("Authorization"), " ", 2)
+	if len(authorization) != 2 {
+		return fmt.Errorf("missing authorization header")
+	}
+
+	switch authorization[0] {
+	case authorizationSecret:
+		if s.apiKeyId != "" {
+			return fmt.Errorf("hmac authorization required")
+		}
+		if !hmac.Equal([]byte(authorization[1]), []byte(s.secret)) {
+			return fmt.Errorf("invalid api secret")
+		}
+		return nil
+	case authorizationHmac:
+		return s.verifyHmac(authorization[1], r, body)
+	}
+	return fmt.Errorf("unsupported authorization scheme '%s'", authorization[0])
+}
+
+// verifyHmac validates an HMAC authorization as calculated by client.CalculateHmac.
+func (s *Server) verifyHmac(encodedHmac string, r *http.Request, body []byte) error {
+	if s.apiKeyId == "" {
+		return fmt.Errorf("hmac authorization not configured")
+	}
+
+	decodedHmac, err := base64.RawStdEncoding.DecodeString(encodedHmac)
+	if err != nil {
+		return fmt.Errorf("failed to decode hmac: %v", err)
+	}
+	hmacJson := &hankoClient.HmacJson{}
+	err = json.Unmarshal(decodedHmac, hmacJson)
+	if err != nil {
+		return fmt.Errorf("failed to decode hmac: %v", err)
+	}
+
+	if hmacJson.ApiKeyId != s.apiKeyId {
+		return fmt.Errorf("unknown api key id '%s'", hmacJson.ApiKeyId)
+	}
+	issuedAt := time.Unix(hmacJson.Time, 0)
+	if time.Since(issuedAt) > hmacValidity || time.Until(issuedAt) > hmacValidity {
+		return fmt.Errorf("hmac expired")
+	}
+	for nonce, expiresAt := range s.nonces {
+		if time.Now().After(expiresAt) {
+			delete(s.nonces, nonce)
+		}
+	}
+	if _, used := s.nonces[hmacJson.Nonce]; used {
+		return fmt.Errorf("hmac nonce has already been used")
+	}
+
+	message := fmt.Sprintf("%s:%d:%s:%s:%s", hmacJson.ApiKeyId, hmacJson.Time, r.Method, r.URL.Path, hmacJson.Nonce)
+	if len(body) > 0 {
+		digest := sha256.Sum256(body)
+		message = fmt.Sprintf("%s:%s", message, hex.EncodeToString(digest[:]))
+	}
+	mac := hmac.New(sha256.New, []byte(s.secret))
+	mac.Write([]byte(message))
+	signature, err := hex.DecodeString(hmacJson.Signature)
+	if err != nil || !hmac.Equal(signature, mac.Sum(nil)) {
+		return fmt.Errorf("invalid hmac signature")
+	}
+
+	s.nonces[hmacJson.Nonce] = issuedAt.Add(2 * hmacValidity)
+	return nil
+}
+
+// recordingResponseWriter records the status code of a response in the RecordedRequest.
+type recordingResponseWriter struct {
+	http.ResponseWriter
+	request *RecordedRequest
+}
+
+func (w *recordingResponseWriter) WriteHeader(statusCode int) {
+	w.request.StatusCode = statusCode
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+// writeJSON writes the given value as JSON response with the given status code.
+func writeJSON(w http.ResponseWriter, statusCode int, value interface{}) {
+	buf := &bytes.Buffer{}
+	_ = json.NewEncoder(buf).Encode(value)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_, _ = w.Write(buf.Bytes())
+}
+
+// writeError writes the given ApiError as JSON response.
+func writeError(w http.ResponseWriter, apiErr *hankoClient.ApiError) {
+	response := *apiErr
+	if response.StatusCode == 0 {
+		response.StatusCode = http.StatusInternalServerError
+	}
+	if response.StatusText == "" {
+		response.StatusText = http.StatusText(response.StatusCode)
+	}
+	writeJSON(w, response.StatusCode, &response)
+}
+
+// writeErrorf writes an ApiError with the given status code, message and optional details as JSON response.
+func writeErrorf(w http.ResponseWriter, statusCode int, message string, details ...string) {
+	writeError(w, &hankoClient.ApiError{
+		Message:    message,
+		Details:    strings.Join(details, ": "),
+		StatusCode: statusCode,
+	})
+}
+
+// decodeJSON decodes the given request body into value.
+func decodeJSON(body []byte, value interface{}) error {
+	return json.NewDecoder(bytes.NewReader(body)).Decode(value)
+}
@@ -0,0 +1,369 @@
+package hankotest
+
+import (
+	"encoding/base64"
+	"fmt"
+	hankoClient "github.com/teamhanko/hanko-go/client"
+	"github.com/teamhanko/hanko-go/passlink"
+	"github.com/teamhanko/hanko-go/webauthn"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+const (
+	testApiSecret = "secret"
+	testApiKeyId  = "apiKeyId"
+)
+
+func newCredential(id string, userId string, createdAt time.Time) webauthn.Credential {
+	return webauthn.Credential{
+		Id:        base64.RawURLEncoding.EncodeToString([]byte(id)),
+		CreatedAt: createdAt,
+		Name:      id,
+		User:      hankoClient.User{ID: userId},
+	}
+}
+
+func TestServer_Authorization(t *testing.T) {
+	var tests = []struct {
+		name           string
+		serverApiKeyId string
+		clientSecret   string
+		clientApiKeyId string
+		expectedError  bool
+	}{
+		{name: "secret", clientSecret: testApiSecret},
+		{name: "wrong secret", clientSecret: "wrong", expectedError: true},
+		{name: "hmac", serverApiKeyId: testApiKeyId, clientSecret: testApiSecret, clientApiKeyId: testApiKeyId},
+		{name: "hmac required", serverApiKeyId: testApiKeyId, clientSecret: testApiSecret, expectedError: true},
+		{name: "hmac with wrong secret", serverApiKeyId: testApiKeyId, clientSecret: "wrong", clientApiKeyId: testApiKeyId, expectedError: true},
+		{name: "hmac with unknown api key id", serverApiKeyId: testApiKeyId, clientSecret: testApiSecret, clientApiKeyId: "unknown", expectedError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := NewServer(testApiSecret)
+			defer server.Close()
+			if tt.serverApiKeyId != "" {
+				server.WithHmac(tt.serverApiKeyId)
+			}
+			client := webauthn.NewClient(server.URL, tt.clientSecret).WithoutLogs()
+			if tt.clientApiKeyId != "" {
+				client.WithHmac(tt.clientApiKeyId)
+			}
+
+			_, apiErr := client.ListCredentials(webauthn.NewCredentialQuery())
+			if tt.expectedError != hankoClient.IsUnauthorized(apiErr.AsError()) {
+				t.Errorf("got error %v, expected unauthorized: %t", apiErr, tt.expectedError)
+			}
+		})
+	}
+}
+
+func TestServer_HmacReplay(t *testing.T) {
+	server := NewServer(testApiSecret).WithHmac(testApiKeyId)
+	defer server.Close()
+	client := hankoClient.NewClient(server.URL, testApiSecret)
+	client.SetHmac(testApiKeyId)
+
+	request, err := client.NewHttpRequest(http.MethodGet, server.URL+"/v1/webauthn/credentials", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, expectedStatus := range []int{http.StatusOK, http.StatusUnauthorized} {
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = response.Body.Close()
+		if response.StatusCode != expectedStatus {
+			t.Errorf("request %d: got status %d, want %d", i, response.StatusCode, expectedStatus)
+		}
+	}
+}
+
+func TestServer_InitializeRegistration(t *testing.T) {
+	server := NewServer(testApiSecret).WithRelyingParty("example.com", "Example", "https://example.com")
+	defer server.Close()
+	server.AddCredential(newCredential("existing", "user", time.Now()))
+	client := webauthn.NewClient(server.URL, testApiSecret).WithoutLogs()
+
+	user := webauthn.NewRegistrationInitializationUser("user", "john.doe@example.com")
+	request := webauthn.NewRegistrationInitializationRequest(user).
+		WithAuthenticatorSelection(webauthn.NewAuthenticatorSelection().WithUserVerification(webauthn.VerificationRequired))
+	response, apiErr := client.InitializeRegistration(request)
+	if apiErr != nil {
+		t.Fatal(apiErr)
+	}
+
+	options := response.Response
+	if len(options.Challenge) == 0 {
+		t.Error("expected a challenge")
+	}
+	if options.RelyingParty.ID != "example.com" || string(options.User.ID) != "user" {
+		t.Errorf("got relying party %q and user %q", options.RelyingParty.ID, options.User.ID)
+	}
+	if options.AuthenticatorSelection.UserVerification != "required" {
+		t.Errorf("got user verification %q, want required", options.AuthenticatorSelection.UserVerification)
+	}
+	if len(options.CredentialExcludeList) != 1 || string(options.CredentialExcludeList[0].CredentialID) != "existing" {
+		t.Errorf("got exclude list %v, want the existing credential", options.CredentialExcludeList)
+	}
+
+	_, apiErr = client.InitializeRegistration(webauthn.NewRegistrationInitializationRequest(webauthn.RegistrationInitializationUser{}))
+	if !hankoClient.IsValidationError(apiErr.AsError()) {
+		t.Errorf("got %v, want a validation error for a missing user", apiErr)
+	}
+}
+
+func TestServer_InitializeAuthentication(t *testing.T) {
+	server := NewServer(testApiSecret)
+	defer server.Close()
+	server.AddCredential(newCredential("first", "user", time.Now()))
+	server.AddCredential(newCredential("second", "user", time.Now()))
+	server.AddCredential(newCredential("other", "other", time.Now()))
+	client := webauthn.NewClient(server.URL, testApiSecret).WithoutLogs()
+
+	request := webauthn.NewAuthenticationInitializationRequest().WithUser(webauthn.NewAuthenticationInitializationUser("user"))
+	response, apiErr := client.InitializeAuthentication(request)
+	if apiErr != nil {
+		t.Fatal(apiErr)
+	}
+	if len(response.Response.AllowedCredentials) != 2 {
+		t.Errorf("got %d allowed credentials, want 2", len(response.Response.AllowedCredentials))
+	}
+
+	response, apiErr = client.InitializeAuthentication(webauthn.NewAuthenticationInitializationRequest())
+	if apiErr != nil {
+		t.Fatal(apiErr)
+	}
+	if len(response.Response.AllowedCredentials) != 0 {
+		t.Errorf("got %d allowed credentials, want none for a resident key authentication", len(response.Response.AllowedCredentials))
+	}
+
+	request = webauthn.NewAuthenticationInitializationRequest().WithUser(webauthn.NewAuthenticationInitializationUser("unknown"))
+	_, apiErr = client.InitializeAuthentication(request)
+	if !hankoClient.IsNotFound(apiErr.AsError()) {
+		t.Errorf("got %v, want not found for a user without credentials", apiErr)
+	}
+
+	transaction := webauthn.NewTransactionInitializationRequest(webauthn.NewAuthenticationInitializationUser("user")).
+		WithTransaction("transfer 100 EUR")
+	_, apiErr = client.InitializeTransaction(transaction)
+	if apiErr != nil {
+		t.Error(apiErr)
+	}
+}
+
+func TestServer_CeremonyTimeout(t *testing.T) {
+	server := NewServer(testApiSecret)
+	defer server.Close()
+	server.AddCredential(newCredential("existing", "user", time.Now()))
+	client := webauthn.NewClient(server.URL, testApiSecret).WithoutLogs()
+
+	registration, apiErr := client.InitializeRegistration(
+		webauthn.NewRegistrationInitializationRequest(webauthn.NewRegistrationInitializationUser("user", "john.doe@example.com")).
+			WithTimeout(8 * time.Minute))
+	if apiErr != nil {
+		t.Fatal(apiErr)
+	}
+	if registration.Response.Timeout != 480000 {
+		t.Errorf("got timeout %d, want 480000", registration.Response.Timeout)
+	}
+
+	user := webauthn.NewAuthenticationInitializationUser("user")
+	authentication, apiErr := client.InitializeAuthentication(webauthn.NewAuthenticationInitializationRequest().WithUser(user).WithTimeout(45 * time.Second))
+	if apiErr != nil {
+		t.Fatal(apiErr)
+	}
+	if authentication.Response.Timeout != 45000 {
+		t.Errorf("got timeout %d, want 45000", authentication.Response.Timeout)
+	}
+
+	transaction, apiErr := client.InitializeTransaction(webauthn.NewTransactionInitializationRequest(user).WithTransaction("transfer 100 EUR"))
+	if apiErr != nil {
+		t.Fatal(apiErr)
+	}
+	if transaction.Response.Timeout != int(ceremonyTimeout/time.Millisecond) {
+		t.Errorf("got timeout %d, want the default timeout", transaction.Response.Timeout)
+	}
+}
+
+func TestServer_Credentials(t *testing.T) {
+	server := NewServer(testApiSecret)
+	defer server.Close()
+	createdAt := time.Now().Add(-time.Hour)
+	for i := 0; i < 25; i++ {
+		server.AddCredential(newCredential(fmt.Sprintf("credential-%02d", i), "user", createdAt.Add(time.Duration(i)*time.Second)))
+	}
+	server.AddCredential(newCredential("other", "other", createdAt.Add(-time.Second)))
+	client := webauthn.NewClient(server.URL, testApiSecret).WithoutLogs()
+
+	var tests = []struct {
+		name          string
+		query         *webauthn.CredentialQuery
+		expectedCount int
+		expectedFirst string
+	}{
+		{name: "default page", query: webauthn.NewCredentialQuery().WithUserId("user"), expectedCount: 10, expectedFirst: "credential-00"},
+		{name: "second page", query: webauthn.NewCredentialQuery().WithUserId("user").WithPage(2), expectedCount: 10, expectedFirst: "credential-10"},
+		{name: "last page", query: webauthn.NewCredentialQuery().WithUserId("user").WithPage(3), expectedCount: 5, expectedFirst: "credential-20"},
+		{name: "beyond last page", query: webauthn.NewCredentialQuery().WithUserId("user").WithPage(4), expectedCount: 0},
+		{name: "page size", query: webauthn.NewCredentialQuery().WithUserId("user").WithPageSize(30), expectedCount: 25, expectedFirst: "credential-00"},
+		{name: "all users", query: webauthn.NewCredentialQuery().WithPageSize(30), expectedCount: 26, expectedFirst: "other"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			credentials, apiErr := client.ListCredentials(tt.query)
+			if apiErr != nil {
+				t.Fatal(apiErr)
+			}
+			if len(*credentials) != tt.expectedCount {
+				t.Fatalf("got %d credentials, want %d", len(*credentials), tt.expectedCount)
+			}
+			if tt.expectedCount > 0 && (*credentials)[0].Name != tt.expectedFirst {
+				t.Errorf("got first credential %q, want %q", (*credentials)[0].Name, tt.expectedFirst)
+			}
+		})
+	}
+
+	id := base64.RawURLEncoding.EncodeToString([]byte("credential-00"))
+	updated, apiErr := client.UpdateCredential(id, webauthn.NewCredentialUpdateRequest().WithName("renamed"))
+	if apiErr != nil || updated.Name != "renamed" {
+		t.Errorf("got %v, %v, want the renamed credential", updated, apiErr)
+	}
+	credential, apiErr := client.GetCredential(id)
+	if apiErr != nil || credential.Name != "renamed" {
+		t.Errorf("got %v, %v, want the renamed credential", credential, apiErr)
+	}
+	apiErr = client.DeleteCredential(id)
+	if apiErr != nil {
+		t.Error(apiErr)
+	}
+	_, apiErr = client.GetCredential(id)
+	if !hankoClient.IsNotFound(apiErr.AsError()) {
+		t.Errorf("got %v, want not found for a deleted credential", apiErr)
+	}
+	if len(server.Credentials()) != 25 {
+		t.Errorf("got %d credentials, want 25", len(server.Credentials()))
+	}
+}
+
+func TestServer_Passlink(t *testing.T) {
+	server := NewServer(testApiSecret)
+	defer server.Close()
+	client := passlink.NewClient(server.URL, testApiSecret).WithoutLogs()
+
+	request := passlink.NewEmailLinkRequest("user", "john.doe@example.com").WithRedirectTo("https://example.com/finalize")
+	link, apiErr := client.InitializePasslink(&request)
+	if apiErr != nil {
+		t.Fatal(apiErr)
+	}
+	if link.Status != "pending" || link.UserID != "user" || !link.ValidUntil.After(time.Now()) {
+		t.Errorf("got %+v, want a pending passlink", link)
+	}
+
+	_, apiErr = client.FinalizePasslink(link.ID.String())
+	if !hankoClient.IsConflict(apiErr.AsError()) {
+		t.Errorf("got %v, want a conflict when finalizing a pending passlink", apiErr)
+	}
+
+	httpClient := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	response, err := httpClient.Get(fmt.Sprintf("%s/v1/passlink/%s/confirm", server.URL, link.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = response.Body.Close()
+	location, _ := url.Parse(response.Header.Get("Location"))
+	if response.StatusCode != http.StatusSeeOther || location.Host != "example.com" || location.Query().Get(LinkIdParameter) != link.ID.String() {
+		t.Errorf("got status %d and location %q, want a redirect to the redirect url", response.StatusCode, location)
+	}
+
+	link, apiErr = client.FinalizePasslink(link.ID.String())
+	if apiErr != nil || link.Status != "finished" {
+		t.Errorf("got %+v, %v, want a finished passlink", link, apiErr)
+	}
+	_, apiErr = client.FinalizePasslink(link.ID.String())
+	if !hankoClient.IsConflict(apiErr.AsError()) {
+		t.Errorf("got %v, want a conflict when finalizing a finished passlink", apiErr)
+	}
+
+	link, _ = client.InitializePasslink(&request)
+	server.ExpirePasslink(link.ID)
+	if _, apiErr := server.ConfirmPasslink(link.ID); apiErr == nil || apiErr.StatusCode != http.StatusGone {
+		t.Errorf("got %v, want an expired passlink", apiErr)
+	}
+
+	_, apiErr = client.FinalizePasslink("not-a-uuid")
+	if !hankoClient.IsValidationError(apiErr.AsError()) {
+		t.Errorf("got %v, want a validation error for an invalid passlink id", apiErr)
+	}
+}
+
+func TestServer_Hooks(t *testing.T) {
+	server := NewServer(testApiSecret)
+	defer server.Close()
+	client := webauthn.NewClient(server.URL, testApiSecret).WithoutLogs()
+	server.FailNext(http.MethodGet, "/v1/webauthn/credentials", &hankoClient.ApiError{
+		Message:    "maintenance",
+		StatusCode: http.StatusServiceUnavailable,
+	})
+
+	_, apiErr := client.ListCredentials(webauthn.NewCredentialQuery())
+	if !hankoClient.IsServerError(apiErr.AsError()) || apiErr.Message != "maintenance" {
+		t.Errorf("got %v, want the injected error", apiErr)
+	}
+	_, apiErr = client.ListCredentials(webauthn.NewCredentialQuery().WithUserId("user"))
+	if apiErr != nil {
+		t.Errorf("got %v, want the error to be injected only once", apiErr)
+	}
+
+	requests := server.Requests()
+	if len(requests) != 2 {
+		t.Fatalf("got %d recorded requests, want 2", len(requests))
+	}
+	if requests[0].StatusCode != http.StatusServiceUnavailable || requests[1].StatusCode != http.StatusOK {
+		t.Errorf("got status codes %d and %d", requests[0].StatusCode, requests[1].StatusCode)
+	}
+	if requests[1].Query.Get("user_id") != "user" || requests[1].Header.Get("Authorization") == "" {
+		t.Errorf("got %+v, want the recorded query and headers", requests[1])
+	}
+
+	server.ResetRequests()
+	server.AddHook(func(request *RecordedRequest) *hankoClient.ApiError {
+		if request.Method == http.MethodDelete {
+			return &hankoClient.ApiError{Message: "forbidden", StatusCode: http.StatusForbidden}
+		}
+		return nil
+	})
+	apiErr = client.DeleteCredential("any")
+	if !hankoClient.IsUnauthorized(apiErr.AsError()) {
+		t.Errorf("got %v, want the error of the hook", apiErr)
+	}
+	if len(server.Requests()) != 1 {
+		t.Errorf("got %d recorded requests, want 1", len(server.Requests()))
+	}
+}
+
+func TestServer_HookInspectingServer(t *testing.T) {
+	server := NewServer(testApiSecret)
+	defer server.Close()
+	client := webauthn.NewClient(server.URL, testApiSecret).WithoutLogs()
+
+	var recorded, credentials int
+	server.AddHook(func(request *RecordedRequest) *hankoClient.ApiError {
+		recorded = len(server.Requests())
+		credentials = len(server.Credentials())
+		return nil
+	})
+
+	if _, apiErr := client.ListCredentials(webauthn.NewCredentialQuery()); apiErr != nil {
+		t.Fatal(apiErr)
+	}
+	if recorded != 1 || credentials != 0 {
+		t.Errorf("got %d recorded requests and %d credentials, want the hook to see the current request", recorded, credentials)
+	}
+}
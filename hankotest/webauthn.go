@@ -0,0 +1,545 @@
+package hankotest
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"github.com/google/uuid"
+	hankoClient "github.com/teamhanko/hanko-go/client"
+	"github.com/teamhanko/hanko-go/webauthn"
+	"github.com/teamhanko/webauthn/protocol"
+	"github.com/teamhanko/webauthn/protocol/webauthncose"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+type ceremonyType string
+
+const (
+	ceremonyRegistration   ceremonyType = "registration"
+	ceremonyAuthentication ceremonyType = "authentication"
+	ceremonyTransaction    ceremonyType = "transaction"
+)
+
+// ceremony is a ceremony that has been initialized but not yet finalized.
+type ceremony struct {
+	ceremonyType     ceremonyType
+	challenge        protocol.Challenge
+	user             hankoClient.User
+	userVerification webauthn.UserVerificationRequirement
+	attachment       webauthn.AuthenticatorAttachment
+	residentKey      bool
+	allowed          [][]byte
+	transaction      string
+	expiresAt        time.Time
+}
+
+// credential is a registered credential together with its public key.
+type credential struct {
+	webauthn.Credential
+	publicKey []byte
+	signCount uint32
+}
+
+// Credentials returns all credentials registered with the Server, ordered by their creation time.
+func (s *Server) Credentials() []webauthn.Credential {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	credentials := make([]webauthn.Credential, len(s.credentials))
+	for i, c := range s.credentials {
+		credentials[i] = c.Credential
+	}
+	return credentials
+}
+
+// AddCredential adds the given credential to the Server, e.g. to prepare the credentials of a user for testing the
+// credential management. Authentication using the credential is not possible, since its public key is unknown. If not
+// set, the CreatedAt time of the credential defaults to the current time.
+func (s *Server) AddCredential(c webauthn.Credential) *Server {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if c.CreatedAt.IsZero() {
+		c.CreatedAt = time.Now().UTC()
+	}
+	s.credentials = append(s.credentials, &credential{Credential: c})
+	sort.SliceStable(s.credentials, func(i, j int) bool {
+		return s.credentials[i].CreatedAt.Before(s.credentials[j].CreatedAt)
+	})
+	return s
+}
+
+// serveWebauthn dispatches a request to the WebAuthn endpoints.
+func (s *Server) serveWebauthn(w http.ResponseWriter, r *http.Request, segments []string, body []byte) {
+	if segments[0] == "credentials" {
+		s.serveCredentials(w, r, segments[1:], body)
+		return
+	}
+	if len(segments) != 2 || r.Method != http.MethodPost {
+		writeErrorf(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	switch segments[0] + "/" + segments[1] {
+	case "registration/initialize":
+		s.handleInitializeRegistration(w, body)
+	case "registration/finalize":
+		s.handleFinalizeRegistration(w, body)
+	case "authentication/initialize":
+		request := &webauthn.AuthenticationInitializationRequest{}
+		if err := decodeJSON(body, request); err != nil {
+			writeErrorf(w, http.StatusBadRequest, "invalid request body", err.Error())
+			return
+		}
+		s.handleInitializeAuthentication(w, ceremonyAuthentication, request.User, request.Options, "")
+	case "authentication/finalize":
+		s.handleFinalizeAuthentication(w, ceremonyAuthentication, body)
+	case "transaction/initialize":
+		request := &webauthn.TransactionInitializationRequest{}
+		if err := decodeJSON(body, request); err != nil {
+			writeErrorf(w, http.StatusBadRequest, "invalid request body", err.Error())
+			return
+		}
+		if request.User.ID == "" || request.Transaction == "" {
+			writeErrorf(w, http.StatusBadRequest, "invalid request body", "user id and transaction are required")
+			return
+		}
+		s.handleInitializeAuthentication(w, ceremonyTransaction, request.User, request.Options, request.Transaction)
+	case "transaction/finalize":
+		s.handleFinalizeAuthentication(w, ceremonyTransaction, body)
+	default:
+		writeErrorf(w, http.StatusNotFound, "not found")
+	}
+}
+
+// handleInitializeRegistration creates the CredentialCreationOptions for the registration of a new credential.
+func (s *Server) handleInitializeRegistration(w http.ResponseWriter, body []byte) {
+	request := &webauthn.RegistrationInitializationRequest{}
+	if err := decodeJSON(body, request); err != nil {
+		writeErrorf(w, http.StatusBadRequest, "invalid request body", err.Error())
+		return
+	}
+	if request.User.ID == "" || request.User.Name == "" {
+		writeErrorf(w, http.StatusBadRequest, "invalid request body", "user id and name are required")
+		return
+	}
+
+	timeout, apiErr := ceremonyTimeoutOf(request.Options.Timeout)
+	if apiErr != nil {
+		writeError(w, apiErr)
+		return
+	}
+	c, err := s.newCeremony(ceremonyRegistration, request.User, timeout)
+	if err != nil {
+		writeErrorf(w, http.StatusInternalServerError, "failed to create challenge", err.Error())
+		return
+	}
+	selection := protocol.AuthenticatorSelection{UserVerification: protocol.VerificationPreferred}
+	if request.Options.AuthenticatorSelection != nil {
+		c.attachment = request.Options.AuthenticatorSelection.AuthenticatorAttachment
+		c.residentKey = request.Options.ResidentKeyRequirement() == webauthn.ResidentKeyRequired
+		selection.AuthenticatorAttachment = protocol.AuthenticatorAttachment(c.attachment)
+		selection.RequireResidentKey = &c.residentKey
+		if request.Options.AuthenticatorSelection.UserVerification != "" {
+			selection.UserVerification = protocol.UserVerificationRequirement(request.Options.AuthenticatorSelection.UserVerification)
+		}
+	}
+	c.userVerification = webauthn.UserVerificationRequirement(selection.UserVerification)
+	attestation := protocol.ConveyancePreference(request.Options.ConveyancePreference)
+	if attestation == "" {
+		attestation = protocol.PreferNoAttestation
+	}
+
+	excluded := s.credentialDescriptors(request.User.ID)
+	if request.Options.ExcludeCredentials != nil {
+		if excluded, err = toProtocolDescriptors(request.Options.ExcludeCredentials); err != nil {
+			writeErrorf(w, http.StatusBadRequest, "invalid request body", err.Error())
+			return
+		}
+	}
+
+	displayName := request.User.DisplayName
+	if displayName == "" {
+		displayName = request.User.Name
+	}
+	response := &webauthn.RegistrationInitializationResponse{ResidentKey: request.Options.ResidentKeyRequirement(), CredentialCreation: protocol.CredentialCreation{
+		Response: protocol.PublicKeyCredentialCreationOptions{
+			Challenge:    c.challenge,
+			RelyingParty: s.relyingParty,
+			User: protocol.UserEntity{
+				CredentialEntity: protocol.CredentialEntity{Name: request.User.Name},
+				DisplayName:      displayName,
+				ID:               []byte(request.User.ID),
+			},
+			Parameters: []protocol.CredentialParameter{
+				{Type: protocol.PublicKeyCredentialType, Algorithm: webauthncose.AlgES256},
+				{Type: protocol.PublicKeyCredentialType, Algorithm: webauthncose.AlgRS256},
+				{Type: protocol.PublicKeyCredentialType, Algorithm: webauthncose.AlgEdDSA},
+			},
+			AuthenticatorSelection: selection,
+			Timeout:                int(timeout / time.Millisecond),
+			CredentialExcludeList:  excluded,
+			Attestation:            attestation,
+			Extensions:             encodeExtensions(request.Options.Extensions),
+		},
+	}}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// handleFinalizeRegistration verifies the attestation of a new credential and registers it.
+func (s *Server) handleFinalizeRegistration(w http.ResponseWriter, body []byte) {
+	parsed, err := protocol.ParseCredentialCreationResponseBody(bytes.NewReader(body))
+	if err != nil {
+		writeErrorf(w, http.StatusBadRequest, "invalid request body", protocolErrorDetails(err))
+		return
+	}
+
+	c, apiErr := s.takeCeremony(ceremonyRegistration, parsed.Response.CollectedClientData.Challenge)
+	if apiErr != nil {
+		writeError(w, apiErr)
+		return
+	}
+	verifyUser := c.userVerification == webauthn.VerificationRequired
+	err = parsed.Verify(c.challenge.String(), verifyUser, s.relyingParty.ID, s.origin, nil, nil, nil)
+	if err != nil {
+		writeErrorf(w, http.StatusBadRequest, "registration verification failed", protocolErrorDetails(err))
+		return
+	}
+	if s.findCredential(parsed.ID) != nil {
+		writeErrorf(w, http.StatusConflict, "credential already registered")
+		return
+	}
+
+	authData := parsed.Response.AttestationObject.AuthData
+	aaguid, _ := uuid.FromBytes(authData.AttData.AAGUID)
+	now := time.Now().UTC()
+	registered := &credential{
+		Credential: webauthn.Credential{
+			Id:               parsed.ID,
+			CreatedAt:        now,
+			LastUsed:         now,
+			Name:             fmt.Sprintf("Credential %d", len(s.credentials)+1),
+			UserVerification: authData.Flags.UserVerified(),
+			IsResidentKey:    c.residentKey,
+			Authenticator: &webauthn.Authenticator{
+				Aaguid:     aaguid.String(),
+				Attachment: string(c.attachment),
+			},
+			User: c.user,
+		},
+		publicKey: authData.AttData.CredentialPublicKey,
+		signCount: authData.Counter,
+	}
+	s.credentials = append(s.credentials, registered)
+	writeJSON(w, http.StatusOK, &webauthn.RegistrationFinalizationResponse{Credential: registered.Credential})
+}
+
+// handleInitializeAuthentication creates the CredentialRequestOptions for an authentication or a transaction. If the
+// user is unknown, authentication is only possible using a resident credential.
+func (s *Server) handleInitializeAuthentication(w http.ResponseWriter, t ceremonyType, user hankoClient.User, options webauthn.AuthenticationInitializationRequestOptions, transaction string) {
+	timeout, apiErr := ceremonyTimeoutOf(options.Timeout)
+	if apiErr != nil {
+		writeError(w, apiErr)
+		return
+	}
+	c, err := s.newCeremony(t, user, timeout)
+	if err != nil {
+		writeErrorf(w, http.StatusInternalServerError, "failed to create challenge", err.Error())
+		return
+	}
+	c.transaction = transaction
+	c.attachment = options.AuthenticatorAttachment
+	c.userVerification = options.UserVerification
+	if c.userVerification == "" {
+		c.userVerification = webauthn.VerificationPreferred
+	}
+
+	var allowed []protocol.CredentialDescriptor
+	if options.AllowCredentials != nil {
+		if allowed, err = toProtocolDescriptors(options.AllowCredentials); err != nil {
+			writeErrorf(w, http.StatusBadRequest, "invalid request body", err.Error())
+			return
+		}
+		for _, descriptor := range allowed {
+			c.allowed = append(c.allowed, descriptor.CredentialID)
+		}
+	} else if user.ID != "" {
+		allowed = s.credentialDescriptors(user.ID)
+		if len(allowed) == 0 {
+			writeErrorf(w, http.StatusNotFound, "no credentials found for user")
+			return
+		}
+		for _, descriptor := range allowed {
+			c.allowed = append(c.allowed, descriptor.CredentialID)
+		}
+	}
+
+	response := &webauthn.AuthenticationInitializationResponse{CredentialAssertion: protocol.CredentialAssertion{
+		Response: protocol.PublicKeyCredentialRequestOptions{
+			Challenge:          c.challenge,
+			Timeout:            int(timeout / time.Millisecond),
+			RelyingPartyID:     s.relyingParty.ID,
+			AllowedCredentials: allowed,
+			UserVerification:   protocol.UserVerificationRequirement(c.userVerification),
+			Extensions:         encodeExtensions(options.Extensions),
+		},
+	}}
+	if t == ceremonyTransaction {
+		writeJSON(w, http.StatusOK, &webauthn.TransactionInitializationResponse{AuthenticationInitializationResponse: *response})
+		return
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// handleFinalizeAuthentication verifies the assertion of an authentication or a transaction.
+func (s *Server) handleFinalizeAuthentication(w http.ResponseWriter, t ceremonyType, body []byte) {
+	parsed, err := protocol.ParseCredentialRequestResponseBody(bytes.NewReader(body))
+	if err != nil {
+		writeErrorf(w, http.StatusBadRequest, "invalid request body", protocolErrorDetails(err))
+		return
+	}
+
+	c, apiErr := s.takeCeremony(t, parsed.Response.CollectedClientData.Challenge)
+	if apiErr != nil {
+		writeError(w, apiErr)
+		return
+	}
+	used := s.findCredential(parsed.ID)
+	if used == nil || used.publicKey == nil {
+		writeErrorf(w, http.StatusNotFound, "credential not found")
+		return
+	}
+	if c.allowed != nil && !containsCredentialId(c.allowed, parsed.RawID) {
+		writeErrorf(w, http.StatusBadRequest, "credential not allowed")
+		return
+	}
+	if len(parsed.Response.UserHandle) > 0 && string(parsed.Response.UserHandle) != used.User.ID {
+		writeErrorf(w, http.StatusBadRequest, "user handle does not match the credential")
+		return
+	}
+
+	verifyUser := c.userVerification == webauthn.VerificationRequired
+	err = parsed.Verify(c.challenge.String(), s.relyingParty.ID, s.origin, verifyUser, used.publicKey)
+	if err != nil {
+		writeErrorf(w, http.StatusBadRequest, "authentication verification failed", protocolErrorDetails(err))
+		return
+	}
+	signCount := parsed.Response.AuthenticatorData.Counter
+	if (signCount != 0 || used.signCount != 0) && signCount <= used.signCount {
+		writeErrorf(w, http.StatusBadRequest, "authentication verification failed", "sign counter did not increase")
+		return
+	}
+
+	used.signCount = signCount
+	used.LastUsed = time.Now().UTC()
+	response := webauthn.AuthenticationFinalizationResponse{Credential: used.Credential}
+	if t == ceremonyTransaction {
+		writeJSON(w, http.StatusOK, &webauthn.TransactionFinalizationResponse{AuthenticationFinalizationResponse: response})
+		return
+	}
+	writeJSON(w, http.StatusOK, &response)
+}
+
+// serveCredentials serves the credential management endpoints.
+func (s *Server) serveCredentials(w http.ResponseWriter, r *http.Request, segments []string, body []byte) {
+	if len(segments) == 0 {
+		if r.Method != http.MethodGet {
+			writeErrorf(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		s.handleListCredentials(w, r)
+		return
+	}
+
+	c := s.findCredential(segments[0])
+	if len(segments) != 1 || c == nil {
+		writeErrorf(w, http.StatusNotFound, "credential not found")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, &c.Credential)
+	case http.MethodPut:
+		request := &webauthn.CredentialUpdateRequest{}
+		if err := decodeJSON(body, request); err != nil || request.Name == "" {
+			writeErrorf(w, http.StatusBadRequest, "invalid request body", "name is required")
+			return
+		}
+		c.Name = request.Name
+		writeJSON(w, http.StatusOK, &c.Credential)
+	case http.MethodDelete:
+		for i := range s.credentials {
+			if s.credentials[i] == c {
+				s.credentials = append(s.credentials[:i], s.credentials[i+1:]...)
+				break
+			}
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeErrorf(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// handleListCredentials returns a page of the credentials, optionally filtered by user ID. Page numbers start at 1; a
+// page size or page of 0 is treated as if it has not been set.
+func (s *Server) handleListCredentials(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	pageSize, err := parseUint(query.Get("page_size"), defaultPageSize)
+	if err != nil {
+		writeErrorf(w, http.StatusBadRequest, "invalid page size", err.Error())
+		return
+	}
+	page, err := parseUint(query.Get("page"), 1)
+	if err != nil {
+		writeErrorf(w, http.StatusBadRequest, "invalid page", err.Error())
+		return
+	}
+
+	userId := query.Get("user_id")
+	credentials := []webauthn.Credential{}
+	for _, c := range s.credentials {
+		if userId == "" || c.User.ID == userId {
+			credentials = append(credentials, c.Credential)
+		}
+	}
+
+	start := (page - 1) * pageSize
+	if start > len(credentials) {
+		start = len(credentials)
+	}
+	end := start + pageSize
+	if end > len(credentials) {
+		end = len(credentials)
+	}
+	writeJSON(w, http.StatusOK, credentials[start:end])
+}
+
+// ceremonyTimeoutOf returns the timeout of a ceremony for the given requested timeout in milliseconds, the default
+// timeout if none has been requested. The Server only rejects timeouts outside of its own bounds, which are wider than
+// those enforced by the webauthn.Client.
+func ceremonyTimeoutOf(requested uint) (time.Duration, *hankoClient.ApiError) {
+	if requested == 0 {
+		return ceremonyTimeout, nil
+	}
+	timeout := time.Duration(requested) * time.Millisecond
+	if timeout < minCeremonyTimeout || timeout > maxCeremonyTimeout {
+		return 0, &hankoClient.ApiError{StatusCode: http.StatusBadRequest, Message: "invalid request body", Details: "invalid timeout"}
+	}
+	return timeout, nil
+}
+
+// newCeremony creates and stores a new ceremony with a random challenge.
+func (s *Server) newCeremony(t ceremonyType, user hankoClient.User, timeout time.Duration) (*ceremony, error) {
+	challenge, err := protocol.CreateChallenge()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for key, c := range s.ceremonies {
+		if now.After(c.expiresAt) {
+			delete(s.ceremonies, key)
+		}
+	}
+	c := &ceremony{
+		ceremonyType: t,
+		challenge:    challenge,
+		user:         user,
+		expiresAt:    now.Add(timeout),
+	}
+	s.ceremonies[challenge.String()] = c
+	return c, nil
+}
+
+// takeCeremony removes and returns the ceremony of the given type with the given challenge. Every ceremony can only be
+// finalized once.
+func (s *Server) takeCeremony(t ceremonyType, challenge string) (*ceremony, *hankoClient.ApiError) {
+	c, ok := s.ceremonies[challenge]
+	if !ok || c.ceremonyType != t {
+		return nil, &hankoClient.ApiError{Message: "unknown challenge", StatusCode: http.StatusBadRequest}
+	}
+	delete(s.ceremonies, challenge)
+	if time.Now().After(c.expiresAt) {
+		return nil, &hankoClient.ApiError{Message: "challenge expired", StatusCode: http.StatusBadRequest}
+	}
+	return c, nil
+}
+
+// findCredential returns the credential with the given ID, nil if there is none.
+func (s *Server) findCredential(id string) *credential {
+	for _, c := range s.credentials {
+		if c.Id == id {
+			return c
+		}
+	}
+	return nil
+}
+
+// credentialDescriptors returns descriptors of all credentials of the user with the given ID.
+func (s *Server) credentialDescriptors(userId string) []protocol.CredentialDescriptor {
+	var descriptors []protocol.CredentialDescriptor
+	for _, c := range s.credentials {
+		if c.User.ID != userId {
+			continue
+		}
+		id, err := base64.RawURLEncoding.DecodeString(c.Id)
+		if err != nil {
+			continue
+		}
+		descriptors = append(descriptors, protocol.CredentialDescriptor{
+			Type:         protocol.PublicKeyCredentialType,
+			CredentialID: id,
+		})
+	}
+	return descriptors
+}
+
+// toProtocolDescriptors converts the given descriptors into their protocol representation.
+func toProtocolDescriptors(descriptors []webauthn.CredentialDescriptor) ([]protocol.CredentialDescriptor, error) {
+	converted := make([]protocol.CredentialDescriptor, len(descriptors))
+	for i, descriptor := range descriptors {
+		id, err := base64.RawURLEncoding.DecodeString(descriptor.Id)
+		if err != nil {
+			return nil, fmt.Errorf("invalid credential id '%s'", descriptor.Id)
+		}
+		converted[i] = protocol.CredentialDescriptor{Type: protocol.PublicKeyCredentialType, CredentialID: id}
+		for _, transport := range descriptor.Transports {
+			converted[i].Transport = append(converted[i].Transport, protocol.AuthenticatorTransport(transport))
+		}
+	}
+	return converted, nil
+}
+
+// containsCredentialId reports whether ids contains id.
+func containsCredentialId(ids [][]byte, id []byte) bool {
+	for _, allowed := range ids {
+		if bytes.Equal(allowed, id) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseUint parses a query parameter, returning defaultValue if the parameter is empty or 0.
+func parseUint(value string, defaultValue int) (int, error) {
+	if value == "" {
+		return defaultValue, nil
+	}
+	parsed, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		return 0, err
+	}
+	if parsed == 0 {
+		return defaultValue, nil
+	}
+	return int(parsed), nil
+}
+
+// protocolErrorDetails returns the details of an error returned by the webauthn protocol package.
+func protocolErrorDetails(err error) string {
+	if protocolErr, ok := err.(*protocol.Error); ok && protocolErr.DevInfo != "" {
+		return fmt.Sprintf("%s: %s", protocolErr.Details, protocolErr.DevInfo)
+	}
+	return err.Error()
+}
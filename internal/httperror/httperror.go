@@ -0,0 +1,67 @@
+// Package httperror provides the error type and the mapping of errors to HTTP responses shared by the HTTP handlers of
+// the webauthn and passlink packages.
+package httperror
+
+import (
+	"errors"
+	hankoClient "github.com/teamhanko/hanko-go/client"
+	"net/http"
+)
+
+// StatusClientClosedRequest is the non-standard status code used for requests whose context.Context has been canceled,
+// usually because the client closed the connection before the response has been written.
+const StatusClientClosedRequest = 499
+
+// Error is an error with an HTTP status code and a message to be shown to the user.
+type Error struct {
+	StatusCode int    // the HTTP status code of the response
+	Message    string // the message to be shown to the user
+	Err        error  // the underlying error, if any; it is not shown to the user
+}
+
+// NewError creates a new Error with the given status code and message.
+func NewError(statusCode int, message string) *Error {
+	return &Error{StatusCode: statusCode, Message: message}
+}
+
+// Error fulfills the go error interface.
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+// Unwrap returns the underlying error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// MapError implements the MapError functions of the handler packages, which document the mapping.
+func MapError(err error) (statusCode int, message string) {
+	var handlerErr *Error
+	if errors.As(err, &handlerErr) {
+		return handlerErr.StatusCode, handlerErr.Message
+	}
+
+	var apiErr *hankoClient.ApiError
+	if !errors.As(err, &apiErr) {
+		return http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError)
+	}
+	switch {
+	case hankoClient.IsValidationError(apiErr):
+		return http.StatusBadRequest, apiErr.Message
+	case hankoClient.IsNotFound(apiErr):
+		return http.StatusNotFound, apiErr.Message
+	case hankoClient.IsConflict(apiErr):
+		return http.StatusConflict, apiErr.Message
+	case hankoClient.IsRateLimited(apiErr):
+		return http.StatusTooManyRequests, apiErr.Message
+	case hankoClient.IsCanceled(apiErr):
+		return StatusClientClosedRequest, "request canceled"
+	case hankoClient.IsServerError(apiErr), hankoClient.IsNetworkError(apiErr), hankoClient.IsTimeout(apiErr),
+		hankoClient.IsDecodeError(apiErr), hankoClient.IsUnauthorized(apiErr):
+		return http.StatusBadGateway, http.StatusText(http.StatusBadGateway)
+	}
+	return http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError)
+}
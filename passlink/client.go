@@ -3,6 +3,7 @@
 package passlink
 
 import (
+	"context"
 	"fmt"
 	hankoClient "github.com/teamhanko/hanko-go/client"
 	"net/http"
@@ -45,9 +46,15 @@ func (c *Client) getUrl(p urlPath) string {
 // On successful initialization, the Hanko Authentication API will send a message containing a link to the recipient
 // specified in the requestBody LinkRequest and returns a representation of the created Passlink as a Link.
 func (c *Client) InitializePasslink(requestBody *LinkRequest) (response *Link, err *hankoClient.ApiError) {
+	return c.InitializePasslinkContext(context.Background(), requestBody)
+}
+
+// InitializePasslinkContext is like InitializePasslink but uses the given context.Context for the request to the API.
+// The request is aborted when the context is canceled or its deadline is exceeded.
+func (c *Client) InitializePasslinkContext(ctx context.Context, requestBody *LinkRequest) (response *Link, err *hankoClient.ApiError) {
 	response = &Link{}
 	requestUrl := c.getUrl(pathPasslinkInitialize)
-	err = c.client.Request("initialize passlink", http.MethodPost, requestUrl, requestBody, response)
+	err = c.client.RequestContext(hankoClient.WithInitialization(ctx), "initialize passlink", http.MethodPost, requestUrl, requestBody, response)
 	return response, err
 }
 
@@ -58,9 +65,14 @@ func (c *Client) InitializePasslink(requestBody *LinkRequest) (response *Link, e
 // a Link. This response indicates that the status of the Passlink is "finished" and the Passlink can no longer be used
 // to authenticate.
 func (c *Client) FinalizePasslink(linkId string) (response *Link, err *hankoClient.ApiError) {
+	return c.FinalizePasslinkContext(context.Background(), linkId)
+}
+
+// FinalizePasslinkContext is like FinalizePasslink but uses the given context.Context for the request to the API. The
+// request is aborted when the context is canceled or its deadline is exceeded.
+func (c *Client) FinalizePasslinkContext(ctx context.Context, linkId string) (response *Link, err *hankoClient.ApiError) {
 	response = &Link{}
 	requestUrl := fmt.Sprintf(c.getUrl(pathPasslinkFinalize), linkId)
-	err = c.client.Request("finalize passlink", http.MethodPatch, requestUrl, nil, response)
+	err = c.client.RequestContext(ctx, "finalize passlink", http.MethodPatch, requestUrl, nil, response)
 	return response, err
 }
-
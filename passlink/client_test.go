@@ -0,0 +1,62 @@
+package passlink
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const testApiSecret = "test"
+
+func TestClient_InitializePasslink(t *testing.T) {
+	ts := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			if r.Method != http.MethodPost || r.URL.Path != "/v1/passlink/initialize" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(&Link{UserID: "test", Status: "pending"})
+		}),
+	)
+	defer ts.Close()
+	client := NewClient(ts.URL, testApiSecret).WithoutLogs()
+	request := NewEmailLinkRequest("test", "test@example.com")
+	link, err := client.InitializePasslink(&request)
+	if err != nil {
+		t.Error(err)
+		t.Fail()
+	}
+	if link.Status != "pending" {
+		t.Errorf("got status %s, want pending", link.Status)
+	}
+}
+
+func TestClient_FinalizePasslinkContext(t *testing.T) {
+	release := make(chan struct{})
+	ts := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-release:
+			case <-r.Context().Done():
+			}
+		}),
+	)
+	defer ts.Close()
+	defer close(release)
+	client := NewClient(ts.URL, testApiSecret).WithoutLogs()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := client.FinalizePasslinkContext(ctx, "test")
+	if err == nil {
+		t.Error("error expected")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("request did not honour the deadline, took %s", elapsed)
+	}
+}
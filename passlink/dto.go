@@ -100,6 +100,18 @@ func (r LinkRequest) WithRedirectTo(redirectTo string) LinkRequest {
 	return r
 }
 
+// The states of a Passlink as found in Link.Status.
+const (
+	// StatusPending is the status of an initialized Passlink that has not yet been confirmed by the user.
+	StatusPending = "pending"
+
+	// StatusConfirmed is the status of a Passlink that has been confirmed ("clicked") by the user.
+	StatusConfirmed = "confirmed"
+
+	// StatusFinished is the status of a finalized Passlink which can no longer be used to authenticate.
+	StatusFinished = "finished"
+)
+
 // Link is a representation of a Passlink.
 type Link struct {
 	ID         uuid.UUID `json:"id"`
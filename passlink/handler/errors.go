@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"errors"
+	hankoClient "github.com/teamhanko/hanko-go/client"
+	"github.com/teamhanko/hanko-go/internal/httperror"
+	"net/http"
+)
+
+// Error is an error with an HTTP status code and a message to be shown to the user. Return an Error from a
+// SuccessCallback to respond with the given status code and message. The underlying error in Err is not shown to the
+// user.
+type Error = httperror.Error
+
+// NewError creates a new Error with the given status code and message.
+func NewError(statusCode int, message string) *Error {
+	return httperror.NewError(statusCode, message)
+}
+
+var (
+	// ErrMissingLinkId is passed to the FailureCallback if the redirect URL does not contain the ID of a Passlink.
+	ErrMissingLinkId = NewError(http.StatusBadRequest, "missing passlink id")
+
+	// ErrInvalidLinkId is passed to the FailureCallback if the ID of the Passlink is not a valid UUID.
+	ErrInvalidLinkId = NewError(http.StatusBadRequest, "invalid passlink id")
+
+	// ErrUnexpectedLink is passed to the FailureCallback if the API responded with a Passlink other than the one to
+	// finalize.
+	ErrUnexpectedLink = NewError(http.StatusBadGateway, http.StatusText(http.StatusBadGateway))
+
+	// ErrUnexpectedStatus is passed to the FailureCallback if the finalized Passlink does not have the status
+	// passlink.StatusFinished.
+	ErrUnexpectedStatus = NewError(http.StatusBadRequest, "passlink has not been finalized")
+
+	// ErrLinkExpired is passed to the FailureCallback if the Passlink is no longer valid.
+	ErrLinkExpired = NewError(http.StatusGone, "passlink expired")
+)
+
+// FailureCallback writes the response if the Passlink could not be finalized, e.g. by rendering an error page.
+type FailureCallback func(w http.ResponseWriter, r *http.Request, err error)
+
+// WriteError is the default FailureCallback. It writes the status code and message returned by MapError as plain
+// text.
+func WriteError(w http.ResponseWriter, r *http.Request, err error) {
+	statusCode, message := MapError(err)
+	http.Error(w, message, statusCode)
+}
+
+// MapError returns the HTTP status code and the message to be shown to the user for the given error.
+//
+// An Error is mapped to its StatusCode and Message. A client.ApiError for a Passlink that expired results in 410 using
+// the message of the ApiError; all other errors are mapped like by the MapError function of the webauthn handler
+// package, e.g. finalizing a Passlink that has not been confirmed results in 400.
+func MapError(err error) (statusCode int, message string) {
+	var handlerErr *Error
+	var apiErr *hankoClient.ApiError
+	if !errors.As(err, &handlerErr) && errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusGone {
+		return http.StatusGone, apiErr.Message
+	}
+	return httperror.MapError(err)
+}
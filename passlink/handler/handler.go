@@ -0,0 +1,127 @@
+// Package handler provides a net/http handler for the finalization of Passlinks of the Hanko Authentication API.
+//
+// After the user has confirmed ("clicked") a Passlink, the Hanko API redirects to the RedirectTo URL of the
+// passlink.LinkRequest, appending the ID of the Passlink. A Handler serving this URL extracts and validates the ID,
+// finalizes the Passlink using a passlink.Client and checks the finalized Passlink. Callbacks act on the result, e.g.
+// by creating a session or rendering an error page:
+//
+//	h := handler.New(passlinkClient).
+//		OnSuccess(func(w http.ResponseWriter, r *http.Request, link *passlink.Link) error {
+//			if err := createSession(w, link.UserID); err != nil {
+//				return err
+//			}
+//			http.Redirect(w, r, "/", http.StatusSeeOther)
+//			return nil
+//		}).
+//		OnFailure(func(w http.ResponseWriter, r *http.Request, err error) {
+//			statusCode, message := handler.MapError(err)
+//			renderErrorPage(w, statusCode, message)
+//		})
+//	mux.Handle("/passlink/finalize", h)
+package handler
+
+import (
+	"encoding/json"
+	"github.com/google/uuid"
+	"github.com/teamhanko/hanko-go/passlink"
+	"net/http"
+	"time"
+)
+
+// DefaultLinkIdParameter is the name of the query parameter the Hanko API uses to append the ID of the Passlink to
+// the redirect URL.
+const DefaultLinkIdParameter = "link_id"
+
+// SuccessCallback is called with the finalized Passlink and writes the response, e.g. after creating a session for
+// the user of the Passlink. Returning an error passes it to the FailureCallback.
+type SuccessCallback func(w http.ResponseWriter, r *http.Request, link *passlink.Link) error
+
+// Handler is an http.Handler finalizing Passlinks. Create a Handler using New.
+type Handler struct {
+	client          *passlink.Client
+	linkIdParameter string
+	onSuccess       SuccessCallback
+	onFailure       FailureCallback
+	now             func() time.Time
+}
+
+// New creates a new Handler which uses the given passlink.Client to communicate with the Hanko Authentication API.
+func New(client *passlink.Client) *Handler {
+	return &Handler{
+		client:          client,
+		linkIdParameter: DefaultLinkIdParameter,
+		onSuccess:       writeLink,
+		onFailure:       WriteError,
+		now:             time.Now,
+	}
+}
+
+// WithLinkIdParameter sets the name of the query parameter containing the ID of the Passlink. Defaults to
+// DefaultLinkIdParameter.
+func (h *Handler) WithLinkIdParameter(name string) *Handler {
+	h.linkIdParameter = name
+	return h
+}
+
+// OnSuccess sets the SuccessCallback called after a Passlink has been finalized. By default, the finalized Passlink
+// is written as JSON.
+func (h *Handler) OnSuccess(callback SuccessCallback) *Handler {
+	h.onSuccess = callback
+	return h
+}
+
+// OnFailure sets the FailureCallback called if a Passlink could not be finalized. Defaults to WriteError.
+func (h *Handler) OnFailure(callback FailureCallback) *Handler {
+	h.onFailure = callback
+	return h
+}
+
+// ServeHTTP finalizes the Passlink whose ID is given by the query parameter and calls the SuccessCallback if the
+// Passlink has been finalized and is still valid. Otherwise, the FailureCallback is called.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		h.onFailure(w, r, NewError(http.StatusMethodNotAllowed, http.StatusText(http.StatusMethodNotAllowed)))
+		return
+	}
+	link, err := h.finalize(r)
+	if err == nil {
+		err = h.onSuccess(w, r, link)
+	}
+	if err != nil {
+		h.onFailure(w, r, err)
+	}
+}
+
+// finalize finalizes the Passlink whose ID is given by the query parameter and checks the finalized Passlink.
+func (h *Handler) finalize(r *http.Request) (*passlink.Link, error) {
+	linkId := r.URL.Query().Get(h.linkIdParameter)
+	if linkId == "" {
+		return nil, ErrMissingLinkId
+	}
+	id, err := uuid.Parse(linkId)
+	if err != nil {
+		return nil, ErrInvalidLinkId
+	}
+
+	link, apiErr := h.client.FinalizePasslinkContext(r.Context(), id.String())
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if link.ID != id {
+		return nil, ErrUnexpectedLink
+	}
+	if link.Status != passlink.StatusFinished {
+		return nil, ErrUnexpectedStatus
+	}
+	if !link.ValidUntil.IsZero() && h.now().After(link.ValidUntil) {
+		return nil, ErrLinkExpired
+	}
+	return link, nil
+}
+
+// writeLink is the default SuccessCallback. It writes the finalized Passlink as JSON.
+func writeLink(w http.ResponseWriter, r *http.Request, link *passlink.Link) error {
+	w.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(w).Encode(link)
+}
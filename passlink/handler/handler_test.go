@@ -0,0 +1,179 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	hankoClient "github.com/teamhanko/hanko-go/client"
+	"github.com/teamhanko/hanko-go/hankotest"
+	"github.com/teamhanko/hanko-go/passlink"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const testApiSecret = "secret"
+
+func initializePasslink(t *testing.T, client *passlink.Client, redirectTo string) *passlink.Link {
+	t.Helper()
+	link, apiErr := client.InitializePasslink(&passlink.LinkRequest{
+		UserID:     "user",
+		Transport:  "email",
+		Email:      "user@example.com",
+		RedirectTo: redirectTo,
+	})
+	if apiErr != nil {
+		t.Fatal(apiErr)
+	}
+	return link
+}
+
+func TestHandler_Finalize(t *testing.T) {
+	api := hankotest.NewServer(testApiSecret)
+	defer api.Close()
+	client := passlink.NewClient(api.URL, testApiSecret).WithoutLogs()
+
+	var finalized *passlink.Link
+	h := New(client).OnSuccess(func(w http.ResponseWriter, r *http.Request, link *passlink.Link) error {
+		finalized = link
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: link.UserID})
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	})
+	ts := httptest.NewServer(h)
+	defer ts.Close()
+
+	// confirming the Passlink redirects to the handler
+	link := initializePasslink(t, client, ts.URL+"/passlink/finalize")
+	response, err := http.Get(api.URL + "/v1/passlink/" + link.ID.String() + "/confirm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusNoContent || finalized == nil || finalized.ID != link.ID {
+		t.Fatalf("got status %d and link %+v, want the link to be finalized", response.StatusCode, finalized)
+	}
+	if finalized.Status != passlink.StatusFinished || finalized.UserID != "user" {
+		t.Errorf("got %+v, want a finished link of the user", finalized)
+	}
+	if len(response.Cookies()) != 1 || response.Cookies()[0].Value != "user" {
+		t.Errorf("got cookies %v, want the session cookie set by the callback", response.Cookies())
+	}
+
+	// a finished Passlink cannot be finalized again
+	response, err = http.Get(ts.URL + "/passlink/finalize?link_id=" + link.ID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusConflict {
+		t.Errorf("got status %d, want 409", response.StatusCode)
+	}
+}
+
+func TestHandler_Errors(t *testing.T) {
+	api := hankotest.NewServer(testApiSecret)
+	defer api.Close()
+	client := passlink.NewClient(api.URL, testApiSecret).WithoutLogs()
+
+	pending := initializePasslink(t, client, "")
+	expired := initializePasslink(t, client, "")
+	api.ExpirePasslink(expired.ID)
+
+	var tests = []struct {
+		name           string
+		method         string
+		query          string
+		expectedStatus int
+		expectedError  error
+	}{
+		{name: "method not allowed", method: http.MethodPost, query: "?link_id=" + pending.ID.String(), expectedStatus: http.StatusMethodNotAllowed},
+		{name: "missing link id", method: http.MethodGet, expectedStatus: http.StatusBadRequest, expectedError: ErrMissingLinkId},
+		{name: "invalid link id", method: http.MethodGet, query: "?link_id=invalid", expectedStatus: http.StatusBadRequest, expectedError: ErrInvalidLinkId},
+		{name: "unknown link", method: http.MethodGet, query: "?link_id=" + uuid.New().String(), expectedStatus: http.StatusNotFound, expectedError: hankoClient.ErrNotFound},
+		{name: "pending link", method: http.MethodGet, query: "?link_id=" + pending.ID.String(), expectedStatus: http.StatusConflict, expectedError: hankoClient.ErrConflict},
+		{name: "expired link", method: http.MethodGet, query: "?link_id=" + expired.ID.String(), expectedStatus: http.StatusGone},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var failure error
+			h := New(client).
+				OnSuccess(func(w http.ResponseWriter, r *http.Request, link *passlink.Link) error {
+					t.Error("unexpected success")
+					return nil
+				}).
+				OnFailure(func(w http.ResponseWriter, r *http.Request, err error) {
+					failure = err
+					WriteError(w, r, err)
+				})
+			recorder := httptest.NewRecorder()
+			h.ServeHTTP(recorder, httptest.NewRequest(tt.method, "/passlink/finalize"+tt.query, nil))
+			if recorder.Code != tt.expectedStatus {
+				t.Errorf("got status %d, want %d", recorder.Code, tt.expectedStatus)
+			}
+			if tt.expectedError != nil && !errors.Is(failure, tt.expectedError) {
+				t.Errorf("got error %v, want %v", failure, tt.expectedError)
+			}
+		})
+	}
+}
+
+func TestHandler_CheckLink(t *testing.T) {
+	linkId := uuid.New()
+	validUntil := time.Now().Add(time.Minute)
+
+	var tests = []struct {
+		name          string
+		link          passlink.Link
+		now           time.Time
+		expectedError error
+	}{
+		{name: "valid", link: passlink.Link{ID: linkId, Status: passlink.StatusFinished, ValidUntil: validUntil}, now: time.Now()},
+		{name: "other link", link: passlink.Link{ID: uuid.New(), Status: passlink.StatusFinished, ValidUntil: validUntil}, now: time.Now(), expectedError: ErrUnexpectedLink},
+		{name: "not finished", link: passlink.Link{ID: linkId, Status: passlink.StatusConfirmed, ValidUntil: validUntil}, now: time.Now(), expectedError: ErrUnexpectedStatus},
+		{name: "expired", link: passlink.Link{ID: linkId, Status: passlink.StatusFinished, ValidUntil: validUntil}, now: validUntil.Add(time.Second), expectedError: ErrLinkExpired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_ = json.NewEncoder(w).Encode(tt.link)
+			}))
+			defer api.Close()
+			h := New(passlink.NewClient(api.URL, testApiSecret).WithoutLogs())
+			h.now = func() time.Time { return tt.now }
+
+			link, err := h.finalize(httptest.NewRequest(http.MethodGet, "/?link_id="+linkId.String(), nil))
+			if !errors.Is(err, tt.expectedError) {
+				t.Errorf("got error %v, want %v", err, tt.expectedError)
+			}
+			if tt.expectedError == nil && (link == nil || link.ID != linkId) {
+				t.Errorf("got link %+v, want %s", link, linkId)
+			}
+		})
+	}
+}
+
+func TestMapError(t *testing.T) {
+	var tests = []struct {
+		name            string
+		err             error
+		expectedStatus  int
+		expectedMessage string
+	}{
+		{name: "handler error", err: ErrLinkExpired, expectedStatus: http.StatusGone, expectedMessage: "passlink expired"},
+		{name: "expired", err: &hankoClient.ApiError{Message: "passlink expired", StatusCode: 410}, expectedStatus: http.StatusGone, expectedMessage: "passlink expired"},
+		{name: "validation", err: &hankoClient.ApiError{Message: "invalid", StatusCode: 400}, expectedStatus: http.StatusBadRequest, expectedMessage: "invalid"},
+		{name: "server error", err: &hankoClient.ApiError{Message: "secret details", StatusCode: 503}, expectedStatus: http.StatusBadGateway, expectedMessage: "Bad Gateway"},
+		{name: "rejected api credentials", err: &hankoClient.ApiError{Message: "unauthorized", StatusCode: 403}, expectedStatus: http.StatusBadGateway, expectedMessage: "Bad Gateway"},
+		{name: "other error", err: errors.New("boom"), expectedStatus: http.StatusInternalServerError, expectedMessage: "Internal Server Error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, message := MapError(tt.err)
+			if status != tt.expectedStatus || message != tt.expectedMessage {
+				t.Errorf("got %d %q, want %d %q", status, message, tt.expectedStatus, tt.expectedMessage)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+// Package passlink provides a variant of the passlink.Client of package github.com/teamhanko/hanko-go/passlink whose
+// methods return the standard error interface instead of a *client.ApiError.
+//
+// Returning a concrete *client.ApiError means that assigning the result to a variable of type error yields a non-nil
+// error even on success. The methods of this package return a nil error on success. On failure, the returned error is
+// a *client.ApiError which can be extracted using errors.As or checked using errors.Is and the predicates of the
+// client package.
+//
+// Migration: Configure a passlink.Client as before and wrap it using NewClient. The request and response types of
+// package github.com/teamhanko/hanko-go/passlink are used unchanged. See package
+// github.com/teamhanko/hanko-go/webauthn/v2 for a migration example.
+package passlink
+
+import (
+	"context"
+	"github.com/teamhanko/hanko-go/passlink"
+)
+
+// Client wraps a passlink.Client and provides the same methods, returning the standard error interface.
+type Client struct {
+	client *passlink.Client
+}
+
+// NewClient wraps the given passlink.Client, e.g.:
+//
+//	client := passlinkv2.NewClient(passlink.NewClient(apiUrl, secret).WithHmac(apiKeyId))
+func NewClient(client *passlink.Client) *Client {
+	return &Client{client: client}
+}
+
+// V1 returns the wrapped passlink.Client.
+func (c *Client) V1() *passlink.Client {
+	return c.client
+}
+
+// InitializePasslink triggers the creation of a new Passlink. See passlink.Client.InitializePasslink.
+func (c *Client) InitializePasslink(requestBody *passlink.LinkRequest) (*passlink.Link, error) {
+	return c.InitializePasslinkContext(context.Background(), requestBody)
+}
+
+// InitializePasslinkContext is like InitializePasslink but uses the given context.Context for the request to the API.
+func (c *Client) InitializePasslinkContext(ctx context.Context, requestBody *passlink.LinkRequest) (*passlink.Link, error) {
+	response, err := c.client.InitializePasslinkContext(ctx, requestBody)
+	return response, err.AsError()
+}
+
+// FinalizePasslink completes a Passlink-based authentication flow. See passlink.Client.FinalizePasslink.
+func (c *Client) FinalizePasslink(linkId string) (*passlink.Link, error) {
+	return c.FinalizePasslinkContext(context.Background(), linkId)
+}
+
+// FinalizePasslinkContext is like FinalizePasslink but uses the given context.Context for the request to the API.
+func (c *Client) FinalizePasslinkContext(ctx context.Context, linkId string) (*passlink.Link, error) {
+	response, err := c.client.FinalizePasslinkContext(ctx, linkId)
+	return response, err.AsError()
+}
@@ -0,0 +1,44 @@
+package passlink
+
+import (
+	"encoding/json"
+	"errors"
+	hankoClient "github.com/teamhanko/hanko-go/client"
+	"github.com/teamhanko/hanko-go/passlink"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const testApiSecret = "test"
+
+func TestClient_FinalizePasslink(t *testing.T) {
+	status := http.StatusOK
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		if status != http.StatusOK {
+			_ = json.NewEncoder(w).Encode(&hankoClient.ApiError{Message: "conflict", StatusCode: status})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(&passlink.Link{Status: "finished"})
+	}))
+	defer ts.Close()
+	client := NewClient(passlink.NewClient(ts.URL, testApiSecret).WithoutLogs())
+
+	var err error
+	link, err := client.FinalizePasslink("test")
+	if err != nil {
+		t.Errorf("expected nil error, got %#v", err)
+	}
+	if link.Status != "finished" {
+		t.Errorf("got status %s, want finished", link.Status)
+	}
+
+	status = http.StatusConflict
+	_, err = client.FinalizePasslink("test")
+	var apiErr *hankoClient.ApiError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusConflict {
+		t.Errorf("got %#v, want a conflict ApiError", err)
+	}
+}
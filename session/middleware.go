@@ -0,0 +1,92 @@
+package session
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// contextKey is the type of the key the Claims are stored under in the context.Context of a request.
+type contextKey struct{}
+
+// UnauthorizedHandler writes the response for a request without a valid session token.
+type UnauthorizedHandler func(w http.ResponseWriter, r *http.Request, err error)
+
+// WithCookie sets the attributes of the session cookie, e.g. its Name, Domain or SameSite mode. The Value, Expires
+// and MaxAge of the given cookie are ignored. By default, a secure, HTTP only cookie named DefaultCookieName with the
+// path "/" and SameSite mode "Lax" is used.
+func (m *Manager) WithCookie(cookie http.Cookie) *Manager {
+	m.cookie = cookie
+	return m
+}
+
+// WithUnauthorizedHandler sets the UnauthorizedHandler called by Middleware for requests without a valid session
+// token. By default, the status 401 is returned.
+func (m *Manager) WithUnauthorizedHandler(handler UnauthorizedHandler) *Manager {
+	m.unauthorizedHandler = handler
+	return m
+}
+
+// SetCookie sets the session cookie containing the given session token. The cookie expires with the token.
+func (m *Manager) SetCookie(w http.ResponseWriter, token string) {
+	cookie := m.cookie
+	cookie.Value = token
+	cookie.MaxAge = int(m.ttl.Seconds())
+	http.SetCookie(w, &cookie)
+}
+
+// ClearCookie deletes the session cookie, e.g. on logout.
+func (m *Manager) ClearCookie(w http.ResponseWriter) {
+	cookie := m.cookie
+	cookie.Value = ""
+	cookie.MaxAge = -1
+	http.SetCookie(w, &cookie)
+}
+
+// Middleware returns an http.Handler which validates the session token of a request before calling the given
+// http.Handler. The Claims of the token can be obtained using ClaimsFromContext. The token is taken from the session
+// cookie or, if there is none, from an "Authorization: Bearer" header. Requests without a valid token are passed to
+// the UnauthorizedHandler.
+//
+// If a token taken from the session cookie expires within the refresh window, it is rotated by setting a new session
+// cookie. Tokens passed in the Authorization header must be refreshed by the client using Refresh.
+func (m *Manager) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, fromCookie := m.token(r)
+		claims, err := m.Validate(token)
+		if err != nil {
+			m.unauthorizedHandler(w, r, err)
+			return
+		}
+		if fromCookie && m.refreshWindow > 0 && m.now().Add(m.refreshWindow).After(claims.ExpiresAt.Time) {
+			// a session that can no longer be refreshed stays valid until it expires
+			if refreshed, err := m.Refresh(claims); err == nil {
+				m.SetCookie(w, refreshed)
+			}
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKey{}, claims)))
+	})
+}
+
+// ClaimsFromContext returns the Claims of the session token validated by Middleware.
+func ClaimsFromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(contextKey{}).(*Claims)
+	return claims, ok
+}
+
+// token returns the session token of the request and whether it has been taken from the session cookie.
+func (m *Manager) token(r *http.Request) (string, bool) {
+	if cookie, err := r.Cookie(m.cookie.Name); err == nil && cookie.Value != "" {
+		return cookie.Value, true
+	}
+	authorization := r.Header.Get("Authorization")
+	if len(authorization) > 7 && strings.EqualFold(authorization[:7], "bearer ") {
+		return authorization[7:], false
+	}
+	return "", false
+}
+
+// writeUnauthorized is the default UnauthorizedHandler.
+func writeUnauthorized(w http.ResponseWriter, r *http.Request, err error) {
+	http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+}
@@ -0,0 +1,265 @@
+// Package session issues signed session tokens after a successful authentication with the Hanko Authentication API.
+//
+// A Manager turns a webauthn.AuthenticationFinalizationResponse or a finalized passlink.Link into a JWT whose subject
+// is the ID of the user and whose claims describe how the user has authenticated. The token can be stored in a cookie
+// using Manager.SetCookie, and Manager.Middleware validates it on subsequent requests and rotates it before it
+// expires:
+//
+//	sessions := session.NewHS256Manager(secret).WithIssuer("https://example.com")
+//
+//	// after the authentication has been finalized
+//	token, err := sessions.IssueForAuthentication(response)
+//	if err != nil {
+//		...
+//	}
+//	sessions.SetCookie(w, token)
+//
+//	// protect handlers
+//	mux.Handle("/account", sessions.Middleware(accountHandler))
+//
+//	// within accountHandler
+//	claims, _ := session.ClaimsFromContext(r.Context())
+//	userId := claims.Subject
+package session
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/teamhanko/hanko-go/passlink"
+	"github.com/teamhanko/hanko-go/webauthn"
+	"net/http"
+	"time"
+)
+
+// AuthMethod is the method a user has authenticated with.
+type AuthMethod string
+
+const (
+	AuthMethodWebAuthn AuthMethod = "webauthn"
+	AuthMethodPasslink AuthMethod = "passlink"
+)
+
+const (
+	// DefaultTTL is the default time to live of a session token.
+	DefaultTTL = time.Hour
+
+	// DefaultRefreshWindow is the default period before the expiry of a session token in which Manager.Middleware
+	// rotates it.
+	DefaultRefreshWindow = 15 * time.Minute
+
+	// DefaultCookieName is the default name of the session cookie.
+	DefaultCookieName = "hanko_session"
+)
+
+var (
+	// ErrInvalidToken indicates that a session token is malformed, has an invalid signature, has expired or has not
+	// been issued for this Manager. The error returned by the Manager also wraps the error of package jwt, e.g.
+	// jwt.ErrTokenExpired.
+	ErrInvalidToken = errors.New("invalid session token")
+
+	// ErrMaxAgeExceeded indicates that a session cannot be refreshed since the user has authenticated longer ago than
+	// the maximum age of a session.
+	ErrMaxAgeExceeded = errors.New("maximum session age exceeded")
+)
+
+// Claims are the claims of a session token. The Subject is the ID of the authenticated user.
+type Claims struct {
+	jwt.RegisteredClaims
+
+	// The method the user has authenticated with.
+	AuthMethod AuthMethod `json:"auth_method"`
+
+	// The time the user has authenticated, which is kept when the session token is refreshed.
+	AuthTime *jwt.NumericDate `json:"auth_time,omitempty"`
+
+	// The ID of the WebAuthn credential the user has authenticated with.
+	CredentialID string `json:"credential_id,omitempty"`
+
+	// Whether the authenticator has verified the user, e.g. using a PIN or biometrics.
+	UserVerification bool `json:"user_verification,omitempty"`
+
+	// The ID of the Passlink the user has authenticated with.
+	PasslinkID string `json:"passlink_id,omitempty"`
+
+	// Custom claims, e.g. set using Manager.WithClaims.
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+}
+
+// ClaimsFunc can modify the Claims of a session token before it is issued, e.g. to add Attributes or to remove the
+// CredentialID. Returning an error prevents the token from being issued.
+type ClaimsFunc func(claims *Claims) error
+
+// Manager issues, validates and refreshes session tokens. Create a Manager using NewHS256Manager or NewES256Manager.
+type Manager struct {
+	method              jwt.SigningMethod
+	signingKey          interface{}
+	verificationKey     interface{}
+	issuer              string
+	audience            []string
+	ttl                 time.Duration
+	refreshWindow       time.Duration
+	maxAge              time.Duration
+	claimsFunc          ClaimsFunc
+	cookie              http.Cookie
+	unauthorizedHandler UnauthorizedHandler
+	now                 func() time.Time
+}
+
+// NewHS256Manager creates a new Manager signing session tokens with HMAC-SHA256 using the given secret, which should
+// be at least 32 bytes long.
+func NewHS256Manager(secret []byte) *Manager {
+	return newManager(jwt.SigningMethodHS256, secret, secret)
+}
+
+// NewES256Manager creates a new Manager signing session tokens with ECDSA using the given P-256 private key. Tokens
+// can be verified by third parties using the corresponding public key.
+func NewES256Manager(privateKey *ecdsa.PrivateKey) *Manager {
+	return newManager(jwt.SigningMethodES256, privateKey, &privateKey.PublicKey)
+}
+
+func newManager(method jwt.SigningMethod, signingKey interface{}, verificationKey interface{}) *Manager {
+	return &Manager{
+		method:          method,
+		signingKey:      signingKey,
+		verificationKey: verificationKey,
+		ttl:             DefaultTTL,
+		refreshWindow:   DefaultRefreshWindow,
+		cookie: http.Cookie{
+			Name:     DefaultCookieName,
+			Path:     "/",
+			Secure:   true,
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+		},
+		unauthorizedHandler: writeUnauthorized,
+		now:                 time.Now,
+	}
+}
+
+// WithIssuer sets the issuer of the session tokens. Tokens of other issuers are rejected.
+func (m *Manager) WithIssuer(issuer string) *Manager {
+	m.issuer = issuer
+	return m
+}
+
+// WithAudience sets the audience of the session tokens. Tokens not issued for one of the given audiences are
+// rejected.
+func (m *Manager) WithAudience(audience ...string) *Manager {
+	m.audience = audience
+	return m
+}
+
+// WithTTL sets the time to live of the session tokens. Defaults to DefaultTTL.
+func (m *Manager) WithTTL(ttl time.Duration) *Manager {
+	m.ttl = ttl
+	return m
+}
+
+// WithRefreshWindow sets the period before the expiry of a session token in which Middleware rotates it. Defaults to
+// DefaultRefreshWindow. A window of zero disables rotation.
+func (m *Manager) WithRefreshWindow(refreshWindow time.Duration) *Manager {
+	m.refreshWindow = refreshWindow
+	return m
+}
+
+// WithMaxAge sets the time after the authentication of the user after which a session can no longer be refreshed, so
+// that the user has to authenticate again. By default, sessions can be refreshed indefinitely.
+func (m *Manager) WithMaxAge(maxAge time.Duration) *Manager {
+	m.maxAge = maxAge
+	return m
+}
+
+// WithClaims sets a ClaimsFunc called before a session token is issued or refreshed.
+func (m *Manager) WithClaims(claimsFunc ClaimsFunc) *Manager {
+	m.claimsFunc = claimsFunc
+	return m
+}
+
+// Issue issues a session token with the given Claims. The ID, issuer, audience, issued at, not before and expiration
+// time claims are set by the Manager, as is the authentication time unless set. Returns an error if the subject is
+// empty.
+func (m *Manager) Issue(claims *Claims) (string, error) {
+	now := m.now()
+	issued := *claims
+	issued.ID = uuid.New().String()
+	issued.Issuer = m.issuer
+	issued.Audience = m.audience
+	issued.IssuedAt = jwt.NewNumericDate(now)
+	issued.NotBefore = jwt.NewNumericDate(now)
+	issued.ExpiresAt = jwt.NewNumericDate(now.Add(m.ttl))
+	if issued.AuthTime == nil {
+		issued.AuthTime = jwt.NewNumericDate(now)
+	}
+	if m.claimsFunc != nil {
+		if err := m.claimsFunc(&issued); err != nil {
+			return "", err
+		}
+	}
+	if issued.Subject == "" {
+		// Validate would reject the token on every request
+		return "", fmt.Errorf("session token has no subject")
+	}
+	return jwt.NewWithClaims(m.method, &issued).SignedString(m.signingKey)
+}
+
+// IssueForAuthentication issues a session token for the user of the credential a WebAuthn authentication has been
+// finalized with. Returns an error if the response does not contain the ID of the user.
+func (m *Manager) IssueForAuthentication(response *webauthn.AuthenticationFinalizationResponse) (string, error) {
+	return m.Issue(&Claims{
+		RegisteredClaims: jwt.RegisteredClaims{Subject: response.Credential.User.ID},
+		AuthMethod:       AuthMethodWebAuthn,
+		CredentialID:     response.Credential.Id,
+		UserVerification: response.Credential.UserVerification,
+	})
+}
+
+// IssueForPasslink issues a session token for the user of a finalized Passlink.
+func (m *Manager) IssueForPasslink(link *passlink.Link) (string, error) {
+	if link.Status != passlink.StatusFinished {
+		return "", fmt.Errorf("passlink %s is %s, not %s", link.ID, link.Status, passlink.StatusFinished)
+	}
+	return m.Issue(&Claims{
+		RegisteredClaims: jwt.RegisteredClaims{Subject: link.UserID},
+		AuthMethod:       AuthMethodPasslink,
+		PasslinkID:       link.ID.String(),
+	})
+}
+
+// Validate verifies the signature and the registered claims of the given session token and returns its Claims.
+// Errors wrap ErrInvalidToken.
+func (m *Manager) Validate(token string) (*Claims, error) {
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{m.method.Alg()}),
+		jwt.WithExpirationRequired(),
+		jwt.WithTimeFunc(m.now),
+	}
+	if m.issuer != "" {
+		options = append(options, jwt.WithIssuer(m.issuer))
+	}
+	if len(m.audience) > 0 {
+		options = append(options, jwt.WithAudience(m.audience...))
+	}
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(*jwt.Token) (interface{}, error) {
+		return m.verificationKey, nil
+	}, options...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: missing subject", ErrInvalidToken)
+	}
+	return claims, nil
+}
+
+// Refresh issues a new session token with the Claims of a validated token, keeping the authentication time. Returns
+// ErrMaxAgeExceeded if the user has authenticated longer ago than the maximum age.
+func (m *Manager) Refresh(claims *Claims) (string, error) {
+	if m.maxAge > 0 && (claims.AuthTime == nil || m.now().After(claims.AuthTime.Add(m.maxAge))) {
+		return "", ErrMaxAgeExceeded
+	}
+	return m.Issue(claims)
+}
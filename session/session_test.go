@@ -0,0 +1,208 @@
+package session
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	hankoClient "github.com/teamhanko/hanko-go/client"
+	"github.com/teamhanko/hanko-go/passlink"
+	"github.com/teamhanko/hanko-go/webauthn"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+var testSecret = []byte("0123456789abcdef0123456789abcdef")
+
+func testAuthentication() *webauthn.AuthenticationFinalizationResponse {
+	return &webauthn.AuthenticationFinalizationResponse{Credential: webauthn.Credential{
+		Id:               "credential",
+		UserVerification: true,
+		User:             hankoClient.User{ID: "user"},
+	}}
+}
+
+func TestManager_Issue(t *testing.T) {
+	privateKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	var tests = []struct {
+		name    string
+		manager *Manager
+	}{
+		{name: "HS256", manager: NewHS256Manager(testSecret)},
+		{name: "ES256", manager: NewES256Manager(privateKey)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := tt.manager.WithIssuer("https://example.com").WithAudience("app").
+				WithClaims(func(claims *Claims) error {
+					claims.Attributes = map[string]interface{}{"role": "admin"}
+					return nil
+				})
+			token, err := manager.IssueForAuthentication(testAuthentication())
+			if err != nil {
+				t.Fatal(err)
+			}
+			claims, err := manager.Validate(token)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if claims.Subject != "user" || claims.AuthMethod != AuthMethodWebAuthn || claims.CredentialID != "credential" ||
+				!claims.UserVerification || claims.Issuer != "https://example.com" || claims.Attributes["role"] != "admin" {
+				t.Errorf("got %+v, want the claims of the authentication", claims)
+			}
+
+			link := &passlink.Link{ID: uuid.New(), UserID: "user", Status: passlink.StatusFinished}
+			token, err = manager.IssueForPasslink(link)
+			if err != nil {
+				t.Fatal(err)
+			}
+			claims, err = manager.Validate(token)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if claims.Subject != "user" || claims.AuthMethod != AuthMethodPasslink || claims.PasslinkID != link.ID.String() {
+				t.Errorf("got %+v, want the claims of the passlink", claims)
+			}
+
+			link.Status = passlink.StatusConfirmed
+			if _, err = manager.IssueForPasslink(link); err == nil {
+				t.Error("expected an error for a passlink that has not been finalized")
+			}
+
+			withoutUser := testAuthentication()
+			withoutUser.Credential.User.ID = ""
+			if _, err = manager.IssueForAuthentication(withoutUser); err == nil {
+				t.Error("expected an error for an authentication without user")
+			}
+			link = &passlink.Link{ID: uuid.New(), Status: passlink.StatusFinished}
+			if _, err = manager.IssueForPasslink(link); err == nil {
+				t.Error("expected an error for a passlink without user")
+			}
+		})
+	}
+}
+
+func TestManager_Validate(t *testing.T) {
+	manager := NewHS256Manager(testSecret).WithIssuer("issuer")
+	token, _ := manager.IssueForAuthentication(testAuthentication())
+	otherKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	esToken, _ := NewES256Manager(otherKey).WithIssuer("issuer").IssueForAuthentication(testAuthentication())
+	otherIssuerToken, _ := NewHS256Manager(testSecret).WithIssuer("other").IssueForAuthentication(testAuthentication())
+	otherSecretToken, _ := NewHS256Manager([]byte("another secret of at least 32 bytes")).WithIssuer("issuer").
+		IssueForAuthentication(testAuthentication())
+
+	var tests = []struct {
+		name          string
+		token         string
+		now           time.Time
+		expectedError error
+	}{
+		{name: "valid", token: token, now: time.Now()},
+		{name: "expired", token: token, now: time.Now().Add(DefaultTTL + time.Minute), expectedError: jwt.ErrTokenExpired},
+		{name: "malformed", token: "token", now: time.Now(), expectedError: jwt.ErrTokenMalformed},
+		{name: "tampered", token: token + "x", now: time.Now(), expectedError: jwt.ErrTokenSignatureInvalid},
+		{name: "other secret", token: otherSecretToken, now: time.Now(), expectedError: jwt.ErrTokenSignatureInvalid},
+		{name: "other algorithm", token: esToken, now: time.Now(), expectedError: jwt.ErrTokenSignatureInvalid},
+		{name: "other issuer", token: otherIssuerToken, now: time.Now(), expectedError: jwt.ErrTokenInvalidIssuer},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager.now = func() time.Time { return tt.now }
+			_, err := manager.Validate(tt.token)
+			if tt.expectedError == nil {
+				if err != nil {
+					t.Errorf("got %v, want a valid token", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidToken) || !errors.Is(err, tt.expectedError) {
+				t.Errorf("got %v, want %v", err, tt.expectedError)
+			}
+		})
+	}
+}
+
+func TestManager_Refresh(t *testing.T) {
+	now := time.Now()
+	manager := NewHS256Manager(testSecret).WithMaxAge(2 * time.Hour)
+	manager.now = func() time.Time { return now }
+	token, _ := manager.IssueForAuthentication(testAuthentication())
+	claims, _ := manager.Validate(token)
+
+	now = now.Add(50 * time.Minute)
+	refreshed, err := manager.Refresh(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	refreshedClaims, err := manager.Validate(refreshed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if refreshedClaims.ID == claims.ID || !refreshedClaims.ExpiresAt.After(claims.ExpiresAt.Time) ||
+		!refreshedClaims.AuthTime.Equal(claims.AuthTime.Time) || refreshedClaims.CredentialID != "credential" {
+		t.Errorf("got %+v, want a rotated token of %+v", refreshedClaims, claims)
+	}
+
+	now = now.Add(2 * time.Hour)
+	if _, err = manager.Refresh(refreshedClaims); !errors.Is(err, ErrMaxAgeExceeded) {
+		t.Errorf("got %v, want %v", err, ErrMaxAgeExceeded)
+	}
+}
+
+func TestManager_Middleware(t *testing.T) {
+	now := time.Now()
+	manager := NewHS256Manager(testSecret)
+	manager.now = func() time.Time { return now }
+	token, _ := manager.IssueForAuthentication(testAuthentication())
+
+	var subject string
+	handler := manager.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, _ := ClaimsFromContext(r.Context())
+		subject = claims.Subject
+	}))
+
+	var tests = []struct {
+		name           string
+		cookie         string
+		authorization  string
+		elapsed        time.Duration
+		expectedStatus int
+		expectRotation bool
+	}{
+		{name: "cookie", cookie: token, expectedStatus: http.StatusOK},
+		{name: "bearer", authorization: "Bearer " + token, expectedStatus: http.StatusOK},
+		{name: "no token", expectedStatus: http.StatusUnauthorized},
+		{name: "invalid token", cookie: "invalid", expectedStatus: http.StatusUnauthorized},
+		{name: "expired", cookie: token, elapsed: DefaultTTL + time.Second, expectedStatus: http.StatusUnauthorized},
+		{name: "rotated", cookie: token, elapsed: DefaultTTL - time.Minute, expectedStatus: http.StatusOK, expectRotation: true},
+		{name: "bearer not rotated", authorization: "Bearer " + token, elapsed: DefaultTTL - time.Minute, expectedStatus: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager.now = func() time.Time { return now.Add(tt.elapsed) }
+			subject = ""
+			request := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.cookie != "" {
+				request.AddCookie(&http.Cookie{Name: DefaultCookieName, Value: tt.cookie})
+			}
+			request.Header.Set("Authorization", tt.authorization)
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, request)
+
+			if recorder.Code != tt.expectedStatus {
+				t.Fatalf("got status %d, want %d", recorder.Code, tt.expectedStatus)
+			}
+			if tt.expectedStatus == http.StatusOK && subject != "user" {
+				t.Errorf("got subject %q, want user", subject)
+			}
+			cookies := recorder.Result().Cookies()
+			if rotated := len(cookies) == 1 && cookies[0].Value != token; rotated != tt.expectRotation {
+				t.Errorf("got cookies %v, want rotation %t", cookies, tt.expectRotation)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package webauthn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// DefaultBulkConcurrency is the default maximum number of concurrent requests made by bulk operations.
+const DefaultBulkConcurrency = 4
+
+// CredentialResult is the result of a bulk operation for a single credential.
+type CredentialResult struct {
+	CredentialID string // the ID of the credential
+	Err          error  // the error that occurred, nil on success; errors of the API are *client.ApiError
+}
+
+// BulkReport reports the results of a bulk operation. Bulk operations continue past failures of single credentials,
+// so that a BulkReport may contain both successful and failed results.
+type BulkReport struct {
+	// The results, one per credential, in the order of the given credential IDs.
+	Results []CredentialResult
+}
+
+// Succeeded returns the IDs of the credentials the operation succeeded for.
+func (r *BulkReport) Succeeded() []string {
+	ids := []string{}
+	for _, result := range r.Results {
+		if result.Err == nil {
+			ids = append(ids, result.CredentialID)
+		}
+	}
+	return ids
+}
+
+// Failed returns the results of the credentials the operation failed for.
+func (r *BulkReport) Failed() []CredentialResult {
+	failed := []CredentialResult{}
+	for _, result := range r.Results {
+		if result.Err != nil {
+			failed = append(failed, result)
+		}
+	}
+	return failed
+}
+
+// Err returns nil if the operation succeeded for all credentials. Otherwise, it returns an error joining the errors of
+// all failed credentials, which can be inspected using errors.Is and errors.As.
+func (r *BulkReport) Err() error {
+	errs := []error{}
+	for _, result := range r.Failed() {
+		errs = append(errs, fmt.Errorf("credential %s: %w", result.CredentialID, result.Err))
+	}
+	return errors.Join(errs...)
+}
+
+// DeleteCredentials deletes the credentials with the given IDs, making up to WithBulkConcurrency requests concurrently.
+// Failures do not stop the operation; inspect the returned BulkReport for the result of each credential.
+func (c *Client) DeleteCredentials(credentialIds []string) *BulkReport {
+	return c.DeleteCredentialsContext(context.Background(), credentialIds)
+}
+
+// DeleteCredentialsContext is like DeleteCredentials but uses the given context.Context for the requests to the API.
+// Once the context is canceled, the remaining credentials fail with the error of the context.
+func (c *Client) DeleteCredentialsContext(ctx context.Context, credentialIds []string) *BulkReport {
+	return c.bulk(ctx, credentialIds, func(credentialId string) error {
+		return c.DeleteCredentialContext(ctx, credentialId).AsError()
+	})
+}
+
+// RenameCredentials renames credentials, mapping the ID of each credential to its new name, making up to
+// WithBulkConcurrency requests concurrently. The results of the returned BulkReport are ordered by credential ID.
+func (c *Client) RenameCredentials(names map[string]string) *BulkReport {
+	return c.RenameCredentialsContext(context.Background(), names)
+}
+
+// RenameCredentialsContext is like RenameCredentials but uses the given context.Context for the requests to the API.
+func (c *Client) RenameCredentialsContext(ctx context.Context, names map[string]string) *BulkReport {
+	credentialIds := make([]string, 0, len(names))
+	for credentialId := range names {
+		credentialIds = append(credentialIds, credentialId)
+	}
+	sort.Strings(credentialIds)
+	return c.bulk(ctx, credentialIds, func(credentialId string) error {
+		_, apiErr := c.UpdateCredentialContext(ctx, credentialId, NewCredentialUpdateRequest().WithName(names[credentialId]))
+		return apiErr.AsError()
+	})
+}
+
+// DeleteAllCredentialsForUser deletes all credentials of the user with the given ID, e.g. when the user deletes their
+// account or reports a lost device. The credentials are listed using ListAllCredentials with DefaultListLimit and
+// deleted using DeleteCredentials. An error is only returned if the credentials could not be listed.
+func (c *Client) DeleteAllCredentialsForUser(userId string) (*BulkReport, error) {
+	return c.DeleteAllCredentialsForUserContext(context.Background(), userId)
+}
+
+// DeleteAllCredentialsForUserContext is like DeleteAllCredentialsForUser but uses the given context.Context for the
+// requests to the API.
+func (c *Client) DeleteAllCredentialsForUserContext(ctx context.Context, userId string) (*BulkReport, error) {
+	if userId == "" {
+		return nil, errors.New("user id must not be empty")
+	}
+	credentials, err := c.ListAllCredentialsContext(ctx, NewCredentialQuery().WithUserId(userId), 0)
+	if err != nil {
+		return nil, err
+	}
+	credentialIds := make([]string, len(credentials))
+	for i, credential := range credentials {
+		credentialIds[i] = credential.Id
+	}
+	return c.DeleteCredentialsContext(ctx, credentialIds), nil
+}
+
+// bulk calls the given operation for each credential, running up to bulkConcurrency operations concurrently.
+func (c *Client) bulk(ctx context.Context, credentialIds []string, operation func(credentialId string) error) *BulkReport {
+	concurrency := c.bulkConcurrency
+	if concurrency <= 0 {
+		concurrency = DefaultBulkConcurrency
+	}
+	report := &BulkReport{Results: make([]CredentialResult, len(credentialIds))}
+	semaphore := make(chan struct{}, concurrency)
+	wg := sync.WaitGroup{}
+	for i, credentialId := range credentialIds {
+		report.Results[i].CredentialID = credentialId
+		if err := ctx.Err(); err != nil {
+			report.Results[i].Err = err
+			continue
+		}
+		semaphore <- struct{}{}
+		wg.Add(1)
+		go func(result *CredentialResult) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+			result.Err = operation(result.CredentialID)
+		}(&report.Results[i])
+	}
+	wg.Wait()
+	return report
+}
@@ -0,0 +1,98 @@
+package webauthn_test
+
+import (
+	"context"
+	hankoClient "github.com/teamhanko/hanko-go/client"
+	"github.com/teamhanko/hanko-go/webauthn"
+	"sync"
+	"testing"
+	"time"
+)
+
+// concurrencyRecorder is a client.Middleware recording the maximum number of concurrent calls.
+type concurrencyRecorder struct {
+	mu       sync.Mutex
+	inFlight int
+	max      int
+}
+
+func (r *concurrencyRecorder) middleware(next hankoClient.Handler) hankoClient.Handler {
+	return func(ctx context.Context, call *hankoClient.Call) *hankoClient.ApiError {
+		r.mu.Lock()
+		r.inFlight++
+		if r.inFlight > r.max {
+			r.max = r.inFlight
+		}
+		r.mu.Unlock()
+		time.Sleep(5 * time.Millisecond)
+		defer func() {
+			r.mu.Lock()
+			r.inFlight--
+			r.mu.Unlock()
+		}()
+		return next(ctx, call)
+	}
+}
+
+func TestClient_DeleteAllCredentialsForUser(t *testing.T) {
+	server := newPaginatingServer()
+	defer server.Close()
+	recorder := &concurrencyRecorder{}
+	client := webauthn.NewClient(server.URL, testCeremonyApiSecret).WithoutLogs().
+		WithBulkConcurrency(3).
+		WithMiddleware(recorder.middleware)
+
+	report, err := client.DeleteAllCredentialsForUser("user")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Succeeded()) != 25 || report.Err() != nil {
+		t.Errorf("got %d deleted credentials and %v, want 25", len(report.Succeeded()), report.Err())
+	}
+	if len(server.Credentials()) != 3 {
+		t.Errorf("got %d remaining credentials, want the 3 of the other user", len(server.Credentials()))
+	}
+	if recorder.max > 3 {
+		t.Errorf("got %d concurrent requests, want at most 3", recorder.max)
+	}
+
+	if _, err = client.DeleteAllCredentialsForUser(""); err == nil {
+		t.Error("expected an error for an empty user id")
+	}
+}
+
+func TestClient_BulkPartialFailure(t *testing.T) {
+	server := newPaginatingServer()
+	defer server.Close()
+	client := webauthn.NewClient(server.URL, testCeremonyApiSecret).WithoutLogs()
+
+	report := client.DeleteCredentials([]string{"credential-25", "unknown", "credential-26"})
+	if len(report.Results) != 3 || report.Results[1].CredentialID != "unknown" {
+		t.Fatalf("got %+v, want one result per credential in order", report.Results)
+	}
+	if succeeded := report.Succeeded(); len(succeeded) != 2 || succeeded[0] != "credential-25" || succeeded[1] != "credential-26" {
+		t.Errorf("got succeeded %v, want the existing credentials", succeeded)
+	}
+	if failed := report.Failed(); len(failed) != 1 || !hankoClient.IsNotFound(failed[0].Err) {
+		t.Errorf("got failed %+v, want the unknown credential", failed)
+	}
+	if !hankoClient.IsNotFound(report.Err()) {
+		t.Errorf("got %v, want the joined errors to match", report.Err())
+	}
+
+	report = client.RenameCredentials(map[string]string{"credential-27": "Security Key", "unknown": "Phone"})
+	if len(report.Succeeded()) != 1 || len(report.Failed()) != 1 {
+		t.Errorf("got %+v, want one renamed and one failed credential", report.Results)
+	}
+	credential, apiErr := client.GetCredential("credential-27")
+	if apiErr != nil || credential.Name != "Security Key" {
+		t.Errorf("got %+v and %v, want the renamed credential", credential, apiErr)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	report = client.DeleteCredentialsContext(ctx, []string{"credential-00"})
+	if len(report.Failed()) != 1 || len(server.Credentials()) != 26 {
+		t.Errorf("got %+v, want no credentials to be deleted after cancellation", report.Results)
+	}
+}
@@ -0,0 +1,361 @@
+package webauthn
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/teamhanko/webauthn/protocol"
+	"sync"
+	"time"
+)
+
+// CeremonyType is the type of a WebAuthn ceremony.
+type CeremonyType string
+
+const (
+	CeremonyRegistration   CeremonyType = "registration"
+	CeremonyAuthentication CeremonyType = "authentication"
+	CeremonyTransaction    CeremonyType = "transaction"
+)
+
+// DefaultCeremonyTimeout is the time a CeremonyClient waits for the finalization of a ceremony by default.
+const DefaultCeremonyTimeout = 5 * time.Minute
+
+// Errors returned by a CeremonyClient if the finalization of a ceremony does not match its initialization. Use
+// errors.Is to check for them.
+var (
+	// ErrCeremonyNotFound indicates that no ceremony has been initialized for the challenge of the finalization
+	// request, or that it has already been finalized.
+	ErrCeremonyNotFound = errors.New("ceremony not found")
+
+	// ErrCeremonyExpired indicates that the ceremony has not been finalized in time.
+	ErrCeremonyExpired = errors.New("ceremony expired")
+
+	// ErrCeremonyMismatch indicates that the ceremony has been initialized with another type, session or user than
+	// the one it is finalized with.
+	ErrCeremonyMismatch = errors.New("ceremony mismatch")
+)
+
+// Ceremony holds the state of an initialized ceremony, recorded by a CeremonyClient to verify the finalization.
+type Ceremony struct {
+	Challenge string       `json:"challenge"`         // the challenge generated by the API, base64url encoded
+	Type      CeremonyType `json:"type"`              // the type of the ceremony
+	SessionID string       `json:"session_id"`        // the session that initialized the ceremony
+	UserID    string       `json:"user_id,omitempty"` // the user the ceremony has been initialized for, if any
+	ExpiresAt time.Time    `json:"expires_at"`        // the time after which the ceremony can no longer be finalized
+}
+
+// CeremonyStore stores the Ceremonies initialized by a CeremonyClient until they are finalized. Implementations must
+// be safe for concurrent use.
+type CeremonyStore interface {
+	// Save stores the given Ceremony. It may be discarded once it has expired.
+	Save(ctx context.Context, ceremony *Ceremony) error
+
+	// Take returns and removes the Ceremony with the given challenge, so that each Ceremony can only be finalized
+	// once. Returns ErrCeremonyNotFound if there is no such Ceremony.
+	Take(ctx context.Context, challenge string) (*Ceremony, error)
+}
+
+// MemoryCeremonyStore is a CeremonyStore keeping the Ceremonies in memory. It is suitable for applications running a
+// single instance; use a KeyValueCeremonyStore to share Ceremonies between instances.
+type MemoryCeremonyStore struct {
+	mu         sync.Mutex
+	ceremonies map[string]*Ceremony
+}
+
+// NewMemoryCeremonyStore creates a new, empty MemoryCeremonyStore.
+func NewMemoryCeremonyStore() *MemoryCeremonyStore {
+	return &MemoryCeremonyStore{ceremonies: map[string]*Ceremony{}}
+}
+
+// Save stores the given Ceremony and discards expired Ceremonies.
+func (s *MemoryCeremonyStore) Save(ctx context.Context, ceremony *Ceremony) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for challenge, c := range s.ceremonies {
+		if now.After(c.ExpiresAt) {
+			delete(s.ceremonies, challenge)
+		}
+	}
+	stored := *ceremony
+	s.ceremonies[ceremony.Challenge] = &stored
+	return nil
+}
+
+// Take returns and removes the Ceremony with the given challenge.
+func (s *MemoryCeremonyStore) Take(ctx context.Context, challenge string) (*Ceremony, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ceremony, ok := s.ceremonies[challenge]
+	if !ok {
+		return nil, ErrCeremonyNotFound
+	}
+	delete(s.ceremonies, challenge)
+	return ceremony, nil
+}
+
+// KeyValueStore is a minimal interface of a key-value store, e.g. Redis or Memcached, used by a KeyValueCeremonyStore.
+type KeyValueStore interface {
+	// Set stores the value under the given key. The store may delete it after the given time to live.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+
+	// GetAndDelete returns the value stored under the given key and deletes it, e.g. using the GETDEL command of
+	// Redis. It must return a nil value if the key does not exist. To prevent replay, getting and deleting should be
+	// atomic.
+	GetAndDelete(ctx context.Context, key string) ([]byte, error)
+}
+
+// KeyValueCeremonyStore is a CeremonyStore keeping the Ceremonies as JSON in a KeyValueStore.
+type KeyValueCeremonyStore struct {
+	store     KeyValueStore
+	keyPrefix string
+}
+
+// NewKeyValueCeremonyStore creates a new KeyValueCeremonyStore using the given KeyValueStore. Keys are prefixed with
+// "hanko:ceremony:" unless configured otherwise using WithKeyPrefix.
+func NewKeyValueCeremonyStore(store KeyValueStore) *KeyValueCeremonyStore {
+	return &KeyValueCeremonyStore{store: store, keyPrefix: "hanko:ceremony:"}
+}
+
+// WithKeyPrefix sets the prefix of the keys the Ceremonies are stored under.
+func (s *KeyValueCeremonyStore) WithKeyPrefix(keyPrefix string) *KeyValueCeremonyStore {
+	s.keyPrefix = keyPrefix
+	return s
+}
+
+// Save stores the given Ceremony until it expires.
+func (s *KeyValueCeremonyStore) Save(ctx context.Context, ceremony *Ceremony) error {
+	value, err := json.Marshal(ceremony)
+	if err != nil {
+		return err
+	}
+	return s.store.Set(ctx, s.keyPrefix+ceremony.Challenge, value, time.Until(ceremony.ExpiresAt))
+}
+
+// Take returns and removes the Ceremony with the given challenge.
+func (s *KeyValueCeremonyStore) Take(ctx context.Context, challenge string) (*Ceremony, error) {
+	value, err := s.store.GetAndDelete(ctx, s.keyPrefix+challenge)
+	if err != nil {
+		return nil, err
+	}
+	if value == nil {
+		return nil, ErrCeremonyNotFound
+	}
+	ceremony := &Ceremony{}
+	if err = json.Unmarshal(value, ceremony); err != nil {
+		return nil, err
+	}
+	return ceremony, nil
+}
+
+// CeremonyClient wraps a Client and binds the finalization of each ceremony to its initialization. On initialization,
+// it records the challenge, type, user and expiry of the ceremony together with an ID of the session that initialized
+// it in a CeremonyStore. On finalization, it takes the recorded Ceremony for the challenge signed by the authenticator
+// and rejects the request if the Ceremony does not exist, has expired or has been initialized by another session or
+// for another type of ceremony. This prevents replaying a response within another session.
+//
+// The user of the credential is only known once the API has finalized the ceremony, so it is checked against the user
+// the ceremony has been initialized for afterwards. On a mismatch, ErrCeremonyMismatch is returned although the API has
+// already acted: a registered credential is deleted again, an authentication or transaction has already updated the
+// sign counter and last use of the credential.
+//
+// The session ID identifies the browser session, e.g. the ID stored in a session cookie. Using an empty session ID
+// only binds finalization to initialization through the challenge.
+//
+// Unlike the methods of Client, the methods of CeremonyClient return the standard error interface. Errors of the API
+// are returned as *client.ApiError.
+type CeremonyClient struct {
+	client  *Client
+	store   CeremonyStore
+	timeout time.Duration
+}
+
+// NewCeremonyClient wraps the given Client, recording ceremonies in the given CeremonyStore.
+func NewCeremonyClient(client *Client, store CeremonyStore) *CeremonyClient {
+	return &CeremonyClient{client: client, store: store, timeout: DefaultCeremonyTimeout}
+}
+
+// WithTimeout sets the time within which ceremonies must be finalized. Defaults to DefaultCeremonyTimeout. If the
+// timeout of an initialization request is longer, e.g. set using RegistrationInitializationRequest.WithTimeout, the
+// ceremony expires after the timeout of the request instead.
+func (c *CeremonyClient) WithTimeout(timeout time.Duration) *CeremonyClient {
+	c.timeout = timeout
+	return c
+}
+
+// Client returns the wrapped Client.
+func (c *CeremonyClient) Client() *Client {
+	return c.client
+}
+
+// InitializeRegistration initializes the registration of a credential on behalf of the given session. See
+// Client.InitializeRegistration.
+func (c *CeremonyClient) InitializeRegistration(sessionId string, requestBody *RegistrationInitializationRequest) (*RegistrationInitializationResponse, error) {
+	return c.InitializeRegistrationContext(context.Background(), sessionId, requestBody)
+}
+
+// InitializeRegistrationContext is like InitializeRegistration but uses the given context.Context.
+func (c *CeremonyClient) InitializeRegistrationContext(ctx context.Context, sessionId string, requestBody *RegistrationInitializationRequest) (*RegistrationInitializationResponse, error) {
+	response, apiErr := c.client.InitializeRegistrationContext(ctx, requestBody)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	err := c.save(ctx, CeremonyRegistration, sessionId, requestBody.User.ID, response.Response.Challenge, requestBody.Options.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// FinalizeRegistration finalizes a registration initialized by the given session. See Client.FinalizeRegistration.
+func (c *CeremonyClient) FinalizeRegistration(sessionId string, requestBody *RegistrationFinalizationRequest) (*RegistrationFinalizationResponse, error) {
+	return c.FinalizeRegistrationContext(context.Background(), sessionId, requestBody)
+}
+
+// FinalizeRegistrationContext is like FinalizeRegistration but uses the given context.Context.
+func (c *CeremonyClient) FinalizeRegistrationContext(ctx context.Context, sessionId string, requestBody *RegistrationFinalizationRequest) (*RegistrationFinalizationResponse, error) {
+	ceremony, err := c.take(ctx, CeremonyRegistration, sessionId, requestBody.AttestationResponse.ClientDataJSON)
+	if err != nil {
+		return nil, err
+	}
+	response, apiErr := c.client.FinalizeRegistrationContext(ctx, requestBody)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if err = checkUser(ceremony, response.Credential.User.ID); err != nil {
+		// the API has already registered the credential, remove it again
+		if apiErr = c.client.DeleteCredentialContext(ctx, response.Credential.Id); apiErr != nil {
+			return nil, fmt.Errorf("%w; failed to delete the registered credential: %v", err, apiErr)
+		}
+		return nil, err
+	}
+	return response, nil
+}
+
+// InitializeAuthentication initializes an authentication on behalf of the given session. See
+// Client.InitializeAuthentication.
+func (c *CeremonyClient) InitializeAuthentication(sessionId string, requestBody *AuthenticationInitializationRequest) (*AuthenticationInitializationResponse, error) {
+	return c.InitializeAuthenticationContext(context.Background(), sessionId, requestBody)
+}
+
+// InitializeAuthenticationContext is like InitializeAuthentication but uses the given context.Context.
+func (c *CeremonyClient) InitializeAuthenticationContext(ctx context.Context, sessionId string, requestBody *AuthenticationInitializationRequest) (*AuthenticationInitializationResponse, error) {
+	response, apiErr := c.client.InitializeAuthenticationContext(ctx, requestBody)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	err := c.save(ctx, CeremonyAuthentication, sessionId, requestBody.User.ID, response.Response.Challenge, requestBody.Options.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// FinalizeAuthentication finalizes an authentication initialized by the given session. See
+// Client.FinalizeAuthentication.
+func (c *CeremonyClient) FinalizeAuthentication(sessionId string, requestBody *AuthenticationFinalizationRequest) (*AuthenticationFinalizationResponse, error) {
+	return c.FinalizeAuthenticationContext(context.Background(), sessionId, requestBody)
+}
+
+// FinalizeAuthenticationContext is like FinalizeAuthentication but uses the given context.Context.
+func (c *CeremonyClient) FinalizeAuthenticationContext(ctx context.Context, sessionId string, requestBody *AuthenticationFinalizationRequest) (*AuthenticationFinalizationResponse, error) {
+	ceremony, err := c.take(ctx, CeremonyAuthentication, sessionId, requestBody.AssertionResponse.ClientDataJSON)
+	if err != nil {
+		return nil, err
+	}
+	response, apiErr := c.client.FinalizeAuthenticationContext(ctx, requestBody)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if err = checkUser(ceremony, response.Credential.User.ID); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// InitializeTransaction initializes a transaction on behalf of the given session. See Client.InitializeTransaction.
+func (c *CeremonyClient) InitializeTransaction(sessionId string, requestBody *TransactionInitializationRequest) (*TransactionInitializationResponse, error) {
+	return c.InitializeTransactionContext(context.Background(), sessionId, requestBody)
+}
+
+// InitializeTransactionContext is like InitializeTransaction but uses the given context.Context.
+func (c *CeremonyClient) InitializeTransactionContext(ctx context.Context, sessionId string, requestBody *TransactionInitializationRequest) (*TransactionInitializationResponse, error) {
+	response, apiErr := c.client.InitializeTransactionContext(ctx, requestBody)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	err := c.save(ctx, CeremonyTransaction, sessionId, requestBody.User.ID, response.Response.Challenge, requestBody.Options.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// FinalizeTransaction finalizes a transaction initialized by the given session. See Client.FinalizeTransaction.
+func (c *CeremonyClient) FinalizeTransaction(sessionId string, requestBody *TransactionFinalizationRequest) (*TransactionFinalizationResponse, error) {
+	return c.FinalizeTransactionContext(context.Background(), sessionId, requestBody)
+}
+
+// FinalizeTransactionContext is like FinalizeTransaction but uses the given context.Context.
+func (c *CeremonyClient) FinalizeTransactionContext(ctx context.Context, sessionId string, requestBody *TransactionFinalizationRequest) (*TransactionFinalizationResponse, error) {
+	ceremony, err := c.take(ctx, CeremonyTransaction, sessionId, requestBody.AssertionResponse.ClientDataJSON)
+	if err != nil {
+		return nil, err
+	}
+	response, apiErr := c.client.FinalizeTransactionContext(ctx, requestBody)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if err = checkUser(ceremony, response.Credential.User.ID); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// save records an initialized ceremony in the CeremonyStore. The ceremony expires after the timeout of the
+// CeremonyClient or the given request timeout in milliseconds, whichever is longer.
+func (c *CeremonyClient) save(ctx context.Context, ceremonyType CeremonyType, sessionId string, userId string, challenge protocol.Challenge, requestTimeout uint) error {
+	timeout := c.timeout
+	if t := time.Duration(requestTimeout) * time.Millisecond; t > timeout {
+		timeout = t
+	}
+	return c.store.Save(ctx, &Ceremony{
+		Challenge: challenge.String(),
+		Type:      ceremonyType,
+		SessionID: sessionId,
+		UserID:    userId,
+		ExpiresAt: time.Now().Add(timeout),
+	})
+}
+
+// take takes the Ceremony for the challenge contained in the given client data from the CeremonyStore and verifies
+// that it can be finalized with the given type by the given session.
+func (c *CeremonyClient) take(ctx context.Context, ceremonyType CeremonyType, sessionId string, clientDataJSON []byte) (*Ceremony, error) {
+	clientData := protocol.CollectedClientData{}
+	if err := json.Unmarshal(clientDataJSON, &clientData); err != nil || clientData.Challenge == "" {
+		return nil, fmt.Errorf("%w: no challenge in client data", ErrCeremonyNotFound)
+	}
+	ceremony, err := c.store.Take(ctx, clientData.Challenge)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case time.Now().After(ceremony.ExpiresAt):
+		return nil, ErrCeremonyExpired
+	case ceremony.Type != ceremonyType:
+		return nil, fmt.Errorf("%w: ceremony is a %s", ErrCeremonyMismatch, ceremony.Type)
+	case ceremony.SessionID != sessionId:
+		return nil, fmt.Errorf("%w: ceremony has been initialized by another session", ErrCeremonyMismatch)
+	}
+	return ceremony, nil
+}
+
+// checkUser verifies that a ceremony initialized for a user has been finalized using a credential of that user.
+func checkUser(ceremony *Ceremony, userId string) error {
+	if ceremony.UserID != "" && ceremony.UserID != userId {
+		return fmt.Errorf("%w: credential belongs to another user", ErrCeremonyMismatch)
+	}
+	return nil
+}
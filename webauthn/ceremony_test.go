@@ -0,0 +1,155 @@
+package webauthn_test
+
+import (
+	"context"
+	"errors"
+	"github.com/teamhanko/hanko-go/hankotest"
+	"github.com/teamhanko/hanko-go/webauthn"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testCeremonyApiSecret = "secret"
+
+// mapKeyValueStore is a webauthn.KeyValueStore standing in for Redis.
+type mapKeyValueStore struct {
+	mu     sync.Mutex
+	values map[string][]byte
+}
+
+func (s *mapKeyValueStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values[key] = value
+	return nil
+}
+
+func (s *mapKeyValueStore) GetAndDelete(ctx context.Context, key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	value := s.values[key]
+	delete(s.values, key)
+	return value, nil
+}
+
+func TestCeremonyClient(t *testing.T) {
+	var stores = []struct {
+		name  string
+		store func() webauthn.CeremonyStore
+	}{
+		{name: "memory", store: func() webauthn.CeremonyStore { return webauthn.NewMemoryCeremonyStore() }},
+		{name: "key-value", store: func() webauthn.CeremonyStore {
+			return webauthn.NewKeyValueCeremonyStore(&mapKeyValueStore{values: map[string][]byte{}})
+		}},
+	}
+	for _, tt := range stores {
+		t.Run(tt.name, func(t *testing.T) {
+			api := hankotest.NewServer(testCeremonyApiSecret)
+			defer api.Close()
+			client := webauthn.NewCeremonyClient(webauthn.NewClient(api.URL, testCeremonyApiSecret).WithoutLogs(), tt.store())
+			authenticator := hankotest.NewAuthenticator()
+
+			registrationInitialization, err := client.InitializeRegistration("session", webauthn.NewRegistrationInitializationRequest(
+				webauthn.NewRegistrationInitializationUser("user", "user@example.com")))
+			if err != nil {
+				t.Fatal(err)
+			}
+			registrationFinalization, _ := authenticator.Register(registrationInitialization)
+			registration, err := client.FinalizeRegistration("session", registrationFinalization)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if registration.Credential.User.ID != "user" {
+				t.Errorf("got user %q, want user", registration.Credential.User.ID)
+			}
+
+			request := webauthn.NewAuthenticationInitializationRequest().WithUser(webauthn.NewAuthenticationInitializationUser("user"))
+			initialization, err := client.InitializeAuthentication("session", request)
+			if err != nil {
+				t.Fatal(err)
+			}
+			finalization, _ := authenticator.Authenticate(initialization)
+			if _, err = client.FinalizeAuthentication("session", finalization); err != nil {
+				t.Fatal(err)
+			}
+			// the ceremony can only be finalized once
+			if _, err = client.FinalizeAuthentication("session", finalization); !errors.Is(err, webauthn.ErrCeremonyNotFound) {
+				t.Errorf("got %v, want %v for a replayed assertion", err, webauthn.ErrCeremonyNotFound)
+			}
+
+			// an assertion cannot be finalized by another session
+			initialization, _ = client.InitializeAuthentication("session", request)
+			finalization, _ = authenticator.Authenticate(initialization)
+			if _, err = client.FinalizeAuthentication("other session", finalization); !errors.Is(err, webauthn.ErrCeremonyMismatch) {
+				t.Errorf("got %v, want %v for another session", err, webauthn.ErrCeremonyMismatch)
+			}
+
+			// an assertion of a transaction cannot be used to authenticate
+			transaction, _ := client.InitializeTransaction("session", webauthn.NewTransactionInitializationRequest(
+				webauthn.NewAuthenticationInitializationUser("user")).WithTransaction("transfer 100 EUR"))
+			transactionFinalization, _ := authenticator.AuthenticateTransaction(transaction)
+			_, err = client.FinalizeAuthentication("session", &transactionFinalization.AuthenticationFinalizationRequest)
+			if !errors.Is(err, webauthn.ErrCeremonyMismatch) {
+				t.Errorf("got %v, want %v for another ceremony type", err, webauthn.ErrCeremonyMismatch)
+			}
+
+			// an assertion for a challenge not issued through the CeremonyClient is rejected
+			initialization, _ = client.Client().InitializeAuthentication(request)
+			finalization, _ = authenticator.Authenticate(initialization)
+			if _, err = client.FinalizeAuthentication("session", finalization); !errors.Is(err, webauthn.ErrCeremonyNotFound) {
+				t.Errorf("got %v, want %v for an unknown challenge", err, webauthn.ErrCeremonyNotFound)
+			}
+
+			client.WithTimeout(-time.Second)
+			initialization, _ = client.InitializeAuthentication("session", request)
+			finalization, _ = authenticator.Authenticate(initialization)
+			if _, err = client.FinalizeAuthentication("session", finalization); !errors.Is(err, webauthn.ErrCeremonyExpired) {
+				t.Errorf("got %v, want %v for an expired ceremony", err, webauthn.ErrCeremonyExpired)
+			}
+
+			// a longer timeout of the request takes precedence
+			initialization, _ = client.InitializeAuthentication("session", request.WithTimeout(time.Minute))
+			finalization, _ = authenticator.Authenticate(initialization)
+			if _, err = client.FinalizeAuthentication("session", finalization); err != nil {
+				t.Errorf("got %v, want the ceremony to expire after the timeout of the request", err)
+			}
+		})
+	}
+}
+
+func TestCeremonyClient_RegistrationUserMismatch(t *testing.T) {
+	api := hankotest.NewServer(testCeremonyApiSecret)
+	defer api.Close()
+	store := webauthn.NewMemoryCeremonyStore()
+	client := webauthn.NewCeremonyClient(webauthn.NewClient(api.URL, testCeremonyApiSecret).WithoutLogs(), store)
+	authenticator := hankotest.NewAuthenticator()
+
+	// the API registers the credential for another user than the one recorded for the ceremony
+	initialization, apiErr := client.Client().InitializeRegistration(webauthn.NewRegistrationInitializationRequest(
+		webauthn.NewRegistrationInitializationUser("other", "other@example.com")))
+	if apiErr != nil {
+		t.Fatal(apiErr)
+	}
+	err := store.Save(context.Background(), &webauthn.Ceremony{
+		Challenge: initialization.Response.Challenge.String(),
+		Type:      webauthn.CeremonyRegistration,
+		SessionID: "session",
+		UserID:    "user",
+		ExpiresAt: time.Now().Add(time.Minute),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	finalization, err := authenticator.Register(initialization)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = client.FinalizeRegistration("session", finalization); !errors.Is(err, webauthn.ErrCeremonyMismatch) {
+		t.Errorf("got %v, want %v for another user", err, webauthn.ErrCeremonyMismatch)
+	}
+	if credentials := api.Credentials(); len(credentials) != 0 {
+		t.Errorf("got %d credentials, want the registered credential to be deleted", len(credentials))
+	}
+}
@@ -0,0 +1,209 @@
+package webauthn
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// CleanupAction is the action a StaleCredentialCleanup performs on stale credentials.
+type CleanupAction string
+
+const (
+	// Deletes stale credentials.
+	CleanupDelete CleanupAction = "delete"
+
+	// Renames stale credentials, e.g. to let users decide whether to delete them.
+	CleanupRename CleanupAction = "rename"
+)
+
+// DefaultStalePrefix is the prefix the default rename function of a StaleCredentialCleanup adds to the names of stale
+// credentials.
+const DefaultStalePrefix = "[unused] "
+
+// StaleCredentialCleanup finds credentials that have not been used for a configurable period and deletes or renames
+// them. A credential is stale if it has been used last before the period, or if it has never been used and has been
+// created before the period. Create a StaleCredentialCleanup using NewStaleCredentialCleanup:
+//
+//	report, err := webauthn.NewStaleCredentialCleanup(client, 180*24*time.Hour).
+//		WithAction(webauthn.CleanupDelete).
+//		WithRateLimit(100 * time.Millisecond).
+//		Run(ctx)
+//
+// Run performs a dry run unless WithDryRun(false) has been set.
+type StaleCredentialCleanup struct {
+	client        *Client
+	unusedFor     time.Duration
+	neverUsedOnly bool
+	query         CredentialQuery
+	action        CleanupAction
+	rename        func(credential Credential) string
+	dryRun        bool
+	interval      time.Duration
+	now           func() time.Time
+}
+
+// CleanupReport reports the result of a StaleCredentialCleanup.
+type CleanupReport struct {
+	DryRun bool          // whether the cleanup has been a dry run
+	Action CleanupAction // the action performed on the Stale credentials
+	Stale  []Credential  // the stale credentials found
+
+	// The results of the action, one per stale credential. Empty for a dry run.
+	BulkReport
+}
+
+// NewStaleCredentialCleanup creates a new StaleCredentialCleanup using the given Client, finding credentials not used
+// for the given period. By default, it checks the credentials of all users, renames stale credentials by adding the
+// DefaultStalePrefix and performs a dry run.
+func NewStaleCredentialCleanup(client *Client, unusedFor time.Duration) *StaleCredentialCleanup {
+	return &StaleCredentialCleanup{
+		client:    client,
+		unusedFor: unusedFor,
+		action:    CleanupRename,
+		rename:    addStalePrefix,
+		dryRun:    true,
+		now:       time.Now,
+	}
+}
+
+// WithNeverUsedOnly restricts the cleanup to credentials that have never been used since their registration.
+func (s *StaleCredentialCleanup) WithNeverUsedOnly(neverUsedOnly bool) *StaleCredentialCleanup {
+	s.neverUsedOnly = neverUsedOnly
+	return s
+}
+
+// WithQuery sets the CredentialQuery selecting the credentials to check, e.g. the credentials of a single user. Its
+// Page and sort order are ignored. Pass nil to check the credentials of all users, which is the default.
+func (s *StaleCredentialCleanup) WithQuery(credentialQuery *CredentialQuery) *StaleCredentialCleanup {
+	if credentialQuery == nil {
+		s.query = CredentialQuery{}
+	} else {
+		s.query = *credentialQuery
+	}
+	return s
+}
+
+// WithAction sets the CleanupAction performed on stale credentials. Defaults to CleanupRename.
+func (s *StaleCredentialCleanup) WithAction(action CleanupAction) *StaleCredentialCleanup {
+	s.action = action
+	return s
+}
+
+// WithRenameFunc sets the function returning the new name of a stale credential for CleanupRename. Credentials whose
+// name would not change are skipped. By default, the DefaultStalePrefix is added.
+func (s *StaleCredentialCleanup) WithRenameFunc(rename func(credential Credential) string) *StaleCredentialCleanup {
+	s.rename = rename
+	return s
+}
+
+// WithDryRun sets whether the cleanup only reports stale credentials without modifying them. Defaults to true.
+func (s *StaleCredentialCleanup) WithDryRun(dryRun bool) *StaleCredentialCleanup {
+	s.dryRun = dryRun
+	return s
+}
+
+// WithRateLimit sets the minimum interval between two requests deleting or renaming a credential. By default, the
+// requests are made with the concurrency configured using Client.WithBulkConcurrency.
+func (s *StaleCredentialCleanup) WithRateLimit(interval time.Duration) *StaleCredentialCleanup {
+	s.interval = interval
+	return s
+}
+
+// IsStale reports whether the given Credential is stale.
+func (s *StaleCredentialCleanup) IsStale(credential Credential) bool {
+	threshold := s.now().Add(-s.unusedFor)
+	neverUsed := credential.LastUsed.IsZero() || !credential.LastUsed.After(credential.CreatedAt)
+	if neverUsed {
+		return credential.CreatedAt.Before(threshold)
+	}
+	return !s.neverUsedOnly && credential.LastUsed.Before(threshold)
+}
+
+// Run walks the credentials selected by the CredentialQuery, finds the stale ones and, unless performing a dry run,
+// deletes or renames them. Failures of single credentials do not stop the cleanup; they are reported in the
+// BulkReport of the CleanupReport. An error is returned if the CleanupAction is unknown or the credentials could not be
+// listed.
+func (s *StaleCredentialCleanup) Run(ctx context.Context) (*CleanupReport, error) {
+	if s.action != CleanupDelete && s.action != CleanupRename {
+		return nil, fmt.Errorf("unknown cleanup action %q", s.action)
+	}
+	query := s.query
+	query.Page = 0
+	query.SortBy = ""
+
+	// stream the credentials instead of listing all of them, so that only the stale ones are kept in memory
+	report := &CleanupReport{DryRun: s.dryRun, Action: s.action, Stale: []Credential{}}
+	names := map[string]string{}
+	it := s.client.IterateCredentialsContext(ctx, &query)
+	for it.Next() {
+		credential := it.Credential()
+		if !s.IsStale(credential) {
+			continue
+		}
+		if s.action == CleanupRename {
+			name := s.rename(credential)
+			if name == credential.Name {
+				continue
+			}
+			names[credential.Id] = name
+		}
+		report.Stale = append(report.Stale, credential)
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+	if s.dryRun {
+		return report, nil
+	}
+
+	credentialIds := make([]string, len(report.Stale))
+	for i, credential := range report.Stale {
+		credentialIds[i] = credential.Id
+	}
+	operation := func(credentialId string) error {
+		if s.action == CleanupDelete {
+			return s.client.DeleteCredentialContext(ctx, credentialId).AsError()
+		}
+		_, apiErr := s.client.UpdateCredentialContext(ctx, credentialId, NewCredentialUpdateRequest().WithName(names[credentialId]))
+		return apiErr.AsError()
+	}
+	if s.interval > 0 {
+		report.BulkReport = *rateLimited(ctx, credentialIds, s.interval, operation)
+	} else {
+		report.BulkReport = *s.client.bulk(ctx, credentialIds, operation)
+	}
+	return report, nil
+}
+
+// rateLimited calls the given operation for each credential one after another, waiting at least the given interval
+// between two calls.
+func rateLimited(ctx context.Context, credentialIds []string, interval time.Duration, operation func(credentialId string) error) *BulkReport {
+	report := &BulkReport{Results: make([]CredentialResult, len(credentialIds))}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for i, credentialId := range credentialIds {
+		report.Results[i].CredentialID = credentialId
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+			case <-ticker.C:
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			report.Results[i].Err = err
+			continue
+		}
+		report.Results[i].Err = operation(credentialId)
+	}
+	return report
+}
+
+// addStalePrefix is the default rename function of a StaleCredentialCleanup.
+func addStalePrefix(credential Credential) string {
+	if strings.HasPrefix(credential.Name, DefaultStalePrefix) {
+		return credential.Name
+	}
+	return DefaultStalePrefix + credential.Name
+}
@@ -0,0 +1,181 @@
+package webauthn_test
+
+import (
+	"context"
+	"fmt"
+	hankoClient "github.com/teamhanko/hanko-go/client"
+	"github.com/teamhanko/hanko-go/hankotest"
+	"github.com/teamhanko/hanko-go/webauthn"
+	"testing"
+	"time"
+)
+
+const day = 24 * time.Hour
+
+// newCleanupServer returns a fake API with credentials that are in use, unused and never used.
+func newCleanupServer() *hankotest.Server {
+	now := time.Now()
+	server := hankotest.NewServer(testCeremonyApiSecret)
+	for _, c := range []webauthn.Credential{
+		{Id: "in-use", Name: "In Use", CreatedAt: now.Add(-400 * day), LastUsed: now.Add(-day)},
+		{Id: "unused", Name: "Unused", CreatedAt: now.Add(-400 * day), LastUsed: now.Add(-200 * day)},
+		{Id: "never-used", Name: "Never Used", CreatedAt: now.Add(-300 * day)},
+		{Id: "new", Name: "New", CreatedAt: now.Add(-day)},
+		{Id: "other", Name: "Other", CreatedAt: now.Add(-300 * day), User: hankoClient.User{ID: "other"}},
+	} {
+		if c.User.ID == "" {
+			c.User.ID = "user"
+		}
+		server.AddCredential(c)
+	}
+	return server
+}
+
+func credentialIds(credentials []webauthn.Credential) map[string]bool {
+	ids := map[string]bool{}
+	for _, credential := range credentials {
+		ids[credential.Id] = true
+	}
+	return ids
+}
+
+func TestStaleCredentialCleanup(t *testing.T) {
+	var tests = []struct {
+		name          string
+		cleanup       func(client *webauthn.Client) *webauthn.StaleCredentialCleanup
+		expectedStale []string
+	}{
+		{
+			name: "unused",
+			cleanup: func(client *webauthn.Client) *webauthn.StaleCredentialCleanup {
+				return webauthn.NewStaleCredentialCleanup(client, 180*day)
+			},
+			expectedStale: []string{"unused", "never-used", "other"},
+		},
+		{
+			name: "never used",
+			cleanup: func(client *webauthn.Client) *webauthn.StaleCredentialCleanup {
+				return webauthn.NewStaleCredentialCleanup(client, 180*day).WithNeverUsedOnly(true)
+			},
+			expectedStale: []string{"never-used", "other"},
+		},
+		{
+			name: "user",
+			cleanup: func(client *webauthn.Client) *webauthn.StaleCredentialCleanup {
+				return webauthn.NewStaleCredentialCleanup(client, 180*day).WithQuery(webauthn.NewCredentialQuery().WithUserId("user"))
+			},
+			expectedStale: []string{"unused", "never-used"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newCleanupServer()
+			defer server.Close()
+			client := webauthn.NewClient(server.URL, testCeremonyApiSecret).WithoutLogs()
+
+			report, err := tt.cleanup(client).Run(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			stale := credentialIds(report.Stale)
+			if len(stale) != len(tt.expectedStale) {
+				t.Errorf("got stale credentials %v, want %v", stale, tt.expectedStale)
+			}
+			for _, id := range tt.expectedStale {
+				if !stale[id] {
+					t.Errorf("expected %s to be stale", id)
+				}
+			}
+			if !report.DryRun || len(report.Results) != 0 || len(server.Credentials()) != 5 {
+				t.Error("expected a dry run not to modify credentials")
+			}
+		})
+	}
+}
+
+func TestStaleCredentialCleanup_Actions(t *testing.T) {
+	server := newCleanupServer()
+	defer server.Close()
+	client := webauthn.NewClient(server.URL, testCeremonyApiSecret).WithoutLogs()
+	cleanup := webauthn.NewStaleCredentialCleanup(client, 180*day).
+		WithQuery(webauthn.NewCredentialQuery().WithUserId("user")).
+		WithDryRun(false)
+
+	report, err := cleanup.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Succeeded()) != 2 || report.Err() != nil {
+		t.Fatalf("got %+v, want the two stale credentials to be renamed", report.Results)
+	}
+	renamed, _ := client.GetCredential("never-used")
+	if renamed.Name != webauthn.DefaultStalePrefix+"Never Used" {
+		t.Errorf("got name %q, want the prefixed name", renamed.Name)
+	}
+
+	// renamed credentials are skipped
+	report, _ = cleanup.Run(context.Background())
+	if len(report.Stale) != 0 {
+		t.Errorf("got %d stale credentials, want renamed credentials to be skipped", len(report.Stale))
+	}
+
+	start := time.Now()
+	report, err = cleanup.WithAction(webauthn.CleanupDelete).WithRateLimit(20 * time.Millisecond).Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Succeeded()) != 2 || len(server.Credentials()) != 3 {
+		t.Errorf("got %+v and %d remaining credentials, want the two stale credentials to be deleted", report.Results, len(server.Credentials()))
+	}
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Errorf("took %s, want the requests to be rate limited", elapsed)
+	}
+}
+
+func TestStaleCredentialCleanup_InvalidOptions(t *testing.T) {
+	server := newCleanupServer()
+	defer server.Close()
+	client := webauthn.NewClient(server.URL, testCeremonyApiSecret).WithoutLogs()
+
+	report, err := webauthn.NewStaleCredentialCleanup(client, 180*day).WithQuery(nil).Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Stale) != 3 {
+		t.Errorf("got %d stale credentials, want the credentials of all users to be checked", len(report.Stale))
+	}
+
+	_, err = webauthn.NewStaleCredentialCleanup(client, 180*day).WithAction("archive").WithDryRun(false).Run(context.Background())
+	if err == nil {
+		t.Error("expected an error for an unknown action")
+	}
+	for _, credential := range server.Credentials() {
+		if credential.Name == "" {
+			t.Errorf("got credential %s without name, want no credential to be renamed", credential.Id)
+		}
+	}
+}
+
+func TestStaleCredentialCleanup_BeyondListLimit(t *testing.T) {
+	now := time.Now()
+	server := hankotest.NewServer(testCeremonyApiSecret)
+	defer server.Close()
+	for i := 0; i <= webauthn.DefaultListLimit; i++ {
+		credential := webauthn.Credential{Id: fmt.Sprintf("credential-%05d", i), CreatedAt: now.Add(-day), User: hankoClient.User{ID: "user"}}
+		if i == webauthn.DefaultListLimit {
+			credential.CreatedAt = now.Add(-400 * day)
+		}
+		server.AddCredential(credential)
+	}
+	client := webauthn.NewClient(server.URL, testCeremonyApiSecret).WithoutLogs()
+
+	report, err := webauthn.NewStaleCredentialCleanup(client, 180*day).
+		WithQuery(webauthn.NewCredentialQuery().WithPageSize(1000)).
+		Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Stale) != 1 || report.Stale[0].Id != fmt.Sprintf("credential-%05d", webauthn.DefaultListLimit) {
+		t.Errorf("got %d stale credentials, want only the last one", len(report.Stale))
+	}
+}
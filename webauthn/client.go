@@ -3,6 +3,7 @@
 package webauthn
 
 import (
+	"context"
 	"fmt"
 	"github.com/google/go-querystring/query"
 	hankoClient "github.com/teamhanko/hanko-go/client"
@@ -25,7 +26,8 @@ const (
 // Client wraps a basic client.Client and provides methods for registration, authentication and webauthn
 // credential management (i.e. credential retrieval, update, and deletion).
 type Client struct {
-	client *hankoClient.Client // the base client.Client to be extended by this package
+	client          *hankoClient.Client // the base client.Client to be extended by this package
+	bulkConcurrency int                 // the number of concurrent requests of bulk operations
 }
 
 // NewClient creates a new webauthn.Client. Provide the baseUrl of the Hanko Authentication API server and your API
@@ -52,19 +54,64 @@ func (c *Client) getUrl(p urlPath) string {
 // the response to your client application in order to pass it to the browser's WebAuthn API's
 // navigator.credentials.create() function.
 func (c *Client) InitializeRegistration(requestBody *RegistrationInitializationRequest) (response *RegistrationInitializationResponse, err *hankoClient.ApiError) {
+	return c.InitializeRegistrationContext(context.Background(), requestBody)
+}
+
+// InitializeRegistrationContext is like InitializeRegistration but uses the given context.Context for the request to
+// the API. The request is aborted when the context is canceled or its deadline is exceeded.
+func (c *Client) InitializeRegistrationContext(ctx context.Context, requestBody *RegistrationInitializationRequest) (response *RegistrationInitializationResponse, err *hankoClient.ApiError) {
 	response = &RegistrationInitializationResponse{}
+	if requestBody == nil {
+		return response, missingRequestBody()
+	}
+	if err = validateTimeout(requestBody.Options.Timeout); err != nil {
+		return response, err
+	}
 	requestUrl := c.getUrl(pathRegistrationInitialize)
-	err = c.client.Request("initialize webauthn registration", http.MethodPost, requestUrl, requestBody, response)
+	err = c.client.RequestContext(hankoClient.WithInitialization(ctx), "initialize webauthn registration", http.MethodPost, requestUrl, requestBody, response)
 	return response, err
 }
 
+// missingRequestBody returns the validation error for a missing request body.
+func missingRequestBody() *hankoClient.ApiError {
+	return &hankoClient.ApiError{
+		Message:    "invalid request body",
+		Details:    "the request body must not be nil",
+		StatusText: http.StatusText(http.StatusBadRequest),
+		StatusCode: http.StatusBadRequest,
+	}
+}
+
+// validateTimeout returns a validation error if the given ceremony timeout in milliseconds is set but not between
+// MinTimeout and MaxTimeout.
+func validateTimeout(timeout uint) *hankoClient.ApiError {
+	if timeout == 0 || (timeout >= uint(MinTimeout.Milliseconds()) && timeout <= uint(MaxTimeout.Milliseconds())) {
+		return nil
+	}
+	return &hankoClient.ApiError{
+		Message:    "invalid timeout",
+		Details:    fmt.Sprintf("the timeout must be between %s and %s", MinTimeout, MaxTimeout),
+		StatusText: http.StatusText(http.StatusBadRequest),
+		StatusCode: http.StatusBadRequest,
+	}
+}
+
 // FinalizeRegistration finalizes the registration request initiated by the InitializeRegistration method. Provide a
 // RegistrationFinalizationRequest which represents the result of calling the browser's WebAuthn API's
 // navigator.credentials.create() function.
 func (c *Client) FinalizeRegistration(requestBody *RegistrationFinalizationRequest) (response *RegistrationFinalizationResponse, err *hankoClient.ApiError) {
+	return c.FinalizeRegistrationContext(context.Background(), requestBody)
+}
+
+// FinalizeRegistrationContext is like FinalizeRegistration but uses the given context.Context for the request to the
+// API. The request is aborted when the context is canceled or its deadline is exceeded.
+func (c *Client) FinalizeRegistrationContext(ctx context.Context, requestBody *RegistrationFinalizationRequest) (response *RegistrationFinalizationResponse, err *hankoClient.ApiError) {
 	response = &RegistrationFinalizationResponse{}
 	requestUrl := c.getUrl(pathRegistrationFinalize)
-	err = c.client.Request("finalize webauthn registration", http.MethodPost, requestUrl, requestBody, response)
+	err = c.client.RequestContext(ctx, "finalize webauthn registration", http.MethodPost, requestUrl, requestBody, response)
+	if err == nil && response.ClientExtensionResults == nil {
+		response.ClientExtensionResults = requestBody.ClientExtensionResults
+	}
 	return response, err
 }
 
@@ -73,22 +120,89 @@ func (c *Client) FinalizeRegistration(requestBody *RegistrationFinalizationReque
 // AuthenticationInitializationResponse. Send the response to your client application in order to pass it to the
 // browser's WebAuthn API's navigator.credentials.get() function.
 func (c *Client) InitializeAuthentication(requestBody *AuthenticationInitializationRequest) (response *AuthenticationInitializationResponse, err *hankoClient.ApiError) {
+	return c.InitializeAuthenticationContext(context.Background(), requestBody)
+}
+
+// InitializeAuthenticationContext is like InitializeAuthentication but uses the given context.Context for the request
+// to the API. The request is aborted when the context is canceled or its deadline is exceeded.
+func (c *Client) InitializeAuthenticationContext(ctx context.Context, requestBody *AuthenticationInitializationRequest) (response *AuthenticationInitializationResponse, err *hankoClient.ApiError) {
 	response = &AuthenticationInitializationResponse{}
+	if requestBody == nil {
+		return response, missingRequestBody()
+	}
+	if err = validateTimeout(requestBody.Options.Timeout); err != nil {
+		return response, err
+	}
+	if err = validateMediation(requestBody); err != nil {
+		return response, err
+	}
 	requestUrl := c.getUrl(pathAuthenticationInitialize)
-	err = c.client.Request("initialize webauthn authentication", http.MethodPost, requestUrl, requestBody, response)
+	err = c.client.RequestContext(hankoClient.WithInitialization(ctx), "initialize webauthn authentication", http.MethodPost, requestUrl, requestBody, response)
+	if err == nil && response.Mediation == "" {
+		response.Mediation = requestBody.Mediation
+	}
 	return response, err
 }
 
+// validateMediation returns a validation error if conditional mediation is requested together with a user or allowed
+// credentials, which would prevent the browser from offering the discoverable credentials in the autofill.
+func validateMediation(requestBody *AuthenticationInitializationRequest) *hankoClient.ApiError {
+	if requestBody == nil {
+		return missingRequestBody()
+	}
+	if requestBody.Mediation != MediationConditional || (requestBody.User.ID == "" && len(requestBody.Options.AllowCredentials) == 0) {
+		return nil
+	}
+	return &hankoClient.ApiError{
+		Message:    "invalid mediation",
+		Details:    "conditional mediation requires neither a user nor allowed credentials to be set",
+		StatusText: http.StatusText(http.StatusBadRequest),
+		StatusCode: http.StatusBadRequest,
+	}
+}
+
 // FinalizeAuthentication finalizes the authentication request initiated by the InitializeAuthentication method. Provide
 // a AuthenticationFinalizationRequest which represents the result of calling the browser's WebAuthn API's
-// navigator.credentials.get() function.
+// navigator.credentials.get() function. If the API does not return the user of the credential, e.g. after conditional
+// mediation, it is resolved by getting the credential from the API. The user handle returned by the authenticator is
+// not used.
 func (c *Client) FinalizeAuthentication(requestBody *AuthenticationFinalizationRequest) (response *AuthenticationFinalizationResponse, err *hankoClient.ApiError) {
+	return c.FinalizeAuthenticationContext(context.Background(), requestBody)
+}
+
+// FinalizeAuthenticationContext is like FinalizeAuthentication but uses the given context.Context for the request to
+// the API. The request is aborted when the context is canceled or its deadline is exceeded.
+func (c *Client) FinalizeAuthenticationContext(ctx context.Context, requestBody *AuthenticationFinalizationRequest) (response *AuthenticationFinalizationResponse, err *hankoClient.ApiError) {
 	response = &AuthenticationFinalizationResponse{}
 	requestUrl := c.getUrl(pathAuthenticationFinalize)
-	err = c.client.Request("finalize webauthn authentication", http.MethodPost, requestUrl, requestBody, response)
+	err = c.client.RequestContext(ctx, "finalize webauthn authentication", http.MethodPost, requestUrl, requestBody, response)
+	if err == nil && response.ClientExtensionResults == nil {
+		response.ClientExtensionResults = requestBody.ClientExtensionResults
+	}
+	if err == nil {
+		err = c.resolveUser(ctx, requestBody, response)
+	}
 	return response, err
 }
 
+// resolveUser sets the user of the credential an authentication has been finalized with if the API did not return it,
+// e.g. after conditional mediation. The user is looked up using the credential, since the user handle is supplied by
+// the browser and not covered by the signature of the assertion.
+func (c *Client) resolveUser(ctx context.Context, requestBody *AuthenticationFinalizationRequest, response *AuthenticationFinalizationResponse) *hankoClient.ApiError {
+	credentialId := response.Credential.Id
+	if credentialId == "" {
+		credentialId = requestBody.ID
+	}
+	if response.Credential.User.ID == "" && credentialId != "" {
+		credential, apiErr := c.GetCredentialContext(ctx, credentialId)
+		if apiErr != nil {
+			return apiErr
+		}
+		response.Credential.User = credential.User
+	}
+	return nil
+}
+
 // InitializeTransaction initiates a transaction. A transaction operation is analogous to the authentication operation,
 // with the main difference being that a transaction context must be provided in the form of a string. This value will
 // become part of the challenge an authenticator signs over during the operation.
@@ -97,9 +211,21 @@ func (c *Client) FinalizeAuthentication(requestBody *AuthenticationFinalizationR
 // Authentication API returns a TransactionInitializationResponse. Send the response to your client application in order
 // to pass it to the browser's WebAuthn API's navigator.credentials.get() function.
 func (c *Client) InitializeTransaction(requestBody *TransactionInitializationRequest) (response *TransactionInitializationResponse, err *hankoClient.ApiError) {
+	return c.InitializeTransactionContext(context.Background(), requestBody)
+}
+
+// InitializeTransactionContext is like InitializeTransaction but uses the given context.Context for the request to the
+// API. The request is aborted when the context is canceled or its deadline is exceeded.
+func (c *Client) InitializeTransactionContext(ctx context.Context, requestBody *TransactionInitializationRequest) (response *TransactionInitializationResponse, err *hankoClient.ApiError) {
 	response = &TransactionInitializationResponse{}
+	if requestBody == nil {
+		return response, missingRequestBody()
+	}
+	if err = validateTimeout(requestBody.Options.Timeout); err != nil {
+		return response, err
+	}
 	requestUrl := c.getUrl(pathTransactionInitialize)
-	err = c.client.Request("initialize webauthn transaction", http.MethodPost, requestUrl, requestBody, response)
+	err = c.client.RequestContext(hankoClient.WithInitialization(ctx), "initialize webauthn transaction", http.MethodPost, requestUrl, requestBody, response)
 	return response, err
 }
 
@@ -107,44 +233,92 @@ func (c *Client) InitializeTransaction(requestBody *TransactionInitializationReq
 // a TransactionFinalizationRequest which represents the result of calling of the browser's WebAuthn API's
 // navigator.credentials.get() function.
 func (c *Client) FinalizeTransaction(requestBody *TransactionFinalizationRequest) (response *TransactionFinalizationResponse, err *hankoClient.ApiError) {
+	return c.FinalizeTransactionContext(context.Background(), requestBody)
+}
+
+// FinalizeTransactionContext is like FinalizeTransaction but uses the given context.Context for the request to the API.
+// The request is aborted when the context is canceled or its deadline is exceeded.
+func (c *Client) FinalizeTransactionContext(ctx context.Context, requestBody *TransactionFinalizationRequest) (response *TransactionFinalizationResponse, err *hankoClient.ApiError) {
 	response = &TransactionFinalizationResponse{}
 	requestUrl := c.getUrl(pathTransactionFinalize)
-	err = c.client.Request("finalize webauthn transaction", http.MethodPost, requestUrl, requestBody, response)
+	err = c.client.RequestContext(ctx, "finalize webauthn transaction", http.MethodPost, requestUrl, requestBody, response)
+	if err == nil && response.ClientExtensionResults == nil {
+		response.ClientExtensionResults = requestBody.ClientExtensionResults
+	}
 	return response, err
 }
 
 // ListCredentials returns a list of Credential. Filter by userId and paginate results using a CredentialQuery.
-// The value for PageSize defaults to 10 and the value for Page to 1.
+// The value for PageSize defaults to 10 and the value for Page to 1. Client-side filters and the sort order of the
+// CredentialQuery would apply to a single page only, so they result in a validation error. Use IterateCredentials or
+// ListAllCredentials instead.
 func (c *Client) ListCredentials(credentialQuery *CredentialQuery) (response *[]Credential, err *hankoClient.ApiError) {
+	return c.ListCredentialsContext(context.Background(), credentialQuery)
+}
+
+// ListCredentialsContext is like ListCredentials but uses the given context.Context for the request to the API. The
+// request is aborted when the context is canceled or its deadline is exceeded.
+func (c *Client) ListCredentialsContext(ctx context.Context, credentialQuery *CredentialQuery) (response *[]Credential, err *hankoClient.ApiError) {
+	if credentialQuery != nil && (credentialQuery.hasFilters() || credentialQuery.SortBy != "") {
+		return &[]Credential{}, &hankoClient.ApiError{
+			Message:    "invalid credential query",
+			Details:    "client-side filters and sorting require IterateCredentials or ListAllCredentials",
+			StatusText: http.StatusText(http.StatusBadRequest),
+			StatusCode: http.StatusBadRequest,
+		}
+	}
+	return c.listCredentials(ctx, credentialQuery)
+}
+
+// listCredentials requests a page of credentials from the API without applying client-side filters.
+func (c *Client) listCredentials(ctx context.Context, credentialQuery *CredentialQuery) (response *[]Credential, err *hankoClient.ApiError) {
 	response = &[]Credential{}
 	requestUrl := c.getUrl(pathCredentials)
 	values, _ := query.Values(credentialQuery)
 	if values != nil {
 		requestUrl += "?" + values.Encode()
 	}
-	err = c.client.Request("list webauthn credentials", http.MethodGet, requestUrl, nil, response)
+	err = c.client.RequestContext(ctx, "list webauthn credentials", http.MethodGet, requestUrl, nil, response)
 	return response, err
 }
 
 // GetCredential returns the Credential with the specified credentialId.
 func (c *Client) GetCredential(credentialId string) (response *Credential, err *hankoClient.ApiError) {
+	return c.GetCredentialContext(context.Background(), credentialId)
+}
+
+// GetCredentialContext is like GetCredential but uses the given context.Context for the request to the API. The request
+// is aborted when the context is canceled or its deadline is exceeded.
+func (c *Client) GetCredentialContext(ctx context.Context, credentialId string) (response *Credential, err *hankoClient.ApiError) {
 	response = &Credential{}
 	requestUrl := fmt.Sprintf("%s/%s", c.getUrl(pathCredentials), credentialId)
-	err = c.client.Request("get webauthn credential", http.MethodGet, requestUrl, nil, response)
+	err = c.client.RequestContext(ctx, "get webauthn credential", http.MethodGet, requestUrl, nil, response)
 	return response, err
 }
 
 // DeleteCredential deletes the Credential with the specified credentialId.
 func (c *Client) DeleteCredential(credentialId string) (err *hankoClient.ApiError) {
+	return c.DeleteCredentialContext(context.Background(), credentialId)
+}
+
+// DeleteCredentialContext is like DeleteCredential but uses the given context.Context for the request to the API. The
+// request is aborted when the context is canceled or its deadline is exceeded.
+func (c *Client) DeleteCredentialContext(ctx context.Context, credentialId string) (err *hankoClient.ApiError) {
 	requestUrl := fmt.Sprintf("%s/%s", c.getUrl(pathCredentials), credentialId)
-	return c.client.Request("delete webauthn credential", http.MethodDelete, requestUrl, nil, nil)
+	return c.client.RequestContext(ctx, "delete webauthn credential", http.MethodDelete, requestUrl, nil, nil)
 }
 
 // UpdateCredential updates the Credential with the specified credentialId. Provide a CredentialUpdateRequest with the
 // updated data. Currently, you can only update the name of a Credential.
 func (c *Client) UpdateCredential(credentialId string, requestBody *CredentialUpdateRequest) (response *Credential, err *hankoClient.ApiError) {
+	return c.UpdateCredentialContext(context.Background(), credentialId, requestBody)
+}
+
+// UpdateCredentialContext is like UpdateCredential but uses the given context.Context for the request to the API. The
+// request is aborted when the context is canceled or its deadline is exceeded.
+func (c *Client) UpdateCredentialContext(ctx context.Context, credentialId string, requestBody *CredentialUpdateRequest) (response *Credential, err *hankoClient.ApiError) {
 	response = &Credential{}
 	requestUrl := fmt.Sprintf("%s/%s", c.getUrl(pathCredentials), credentialId)
-	err = c.client.Request("update webauthn credential", http.MethodPut, requestUrl, requestBody, response)
+	err = c.client.RequestContext(ctx, "update webauthn credential", http.MethodPut, requestUrl, requestBody, response)
 	return response, err
 }
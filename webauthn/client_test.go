@@ -1,11 +1,15 @@
 package webauthn
 
 import (
+	"context"
 	"encoding/json"
+	hankoClient "github.com/teamhanko/hanko-go/client"
 	"net"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 )
 
 const (
@@ -180,3 +184,328 @@ func TestHankoApiClient_UpdateCredential(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestHankoApiClient_InitializationTimeout(t *testing.T) {
+	// no API is running, invalid timeouts must be rejected before making a request
+	client := NewClient(testBaseUrl, testApiSecret).WithoutLogs()
+	user := NewAuthenticationInitializationUser("user")
+
+	var tests = []struct {
+		name    string
+		timeout time.Duration
+		valid   bool
+	}{
+		{name: "too short", timeout: 10 * time.Second},
+		{name: "too long", timeout: time.Hour},
+		{name: "shorter than a millisecond", timeout: time.Microsecond},
+		{name: "negative", timeout: -time.Minute},
+		{name: "minimum", timeout: MinTimeout, valid: true},
+		{name: "maximum", timeout: MaxTimeout, valid: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, registrationErr := client.InitializeRegistration(NewRegistrationInitializationRequest(NewRegistrationInitializationUser("user", "name")).WithTimeout(tt.timeout))
+			_, authenticationErr := client.InitializeAuthentication(NewAuthenticationInitializationRequest().WithUser(user).WithTimeout(tt.timeout))
+			_, transactionErr := client.InitializeTransaction(NewTransactionInitializationRequest(user).WithTimeout(tt.timeout))
+			for _, apiErr := range []*hankoClient.ApiError{registrationErr, authenticationErr, transactionErr} {
+				if hankoClient.IsValidationError(apiErr.AsError()) == tt.valid {
+					t.Errorf("got %v, want a validation error: %t", apiErr, !tt.valid)
+				}
+			}
+		})
+	}
+}
+
+func TestHankoApiClient_ConditionalMediation(t *testing.T) {
+	ts := runTestApi(nil, &AuthenticationInitializationResponse{}, http.StatusOK)
+	ts.Start()
+	defer ts.Close()
+	client := NewClient(testBaseUrl, testApiSecret).WithoutLogs()
+
+	response, apiErr := client.InitializeAuthentication(NewAuthenticationInitializationRequest().WithConditionalMediation())
+	if apiErr != nil {
+		t.Fatal(apiErr)
+	}
+	if response.Mediation != MediationConditional {
+		t.Errorf("got mediation %q, want %q", response.Mediation, MediationConditional)
+	}
+	data, _ := json.Marshal(response)
+	if !strings.Contains(string(data), `"mediation":"conditional"`) {
+		t.Errorf("got %s, want the mediation to be passed to navigator.credentials.get()", data)
+	}
+
+	var invalid = []struct {
+		name    string
+		request *AuthenticationInitializationRequest
+	}{
+		{
+			name:    "with user",
+			request: NewAuthenticationInitializationRequest().WithConditionalMediation().WithUser(NewAuthenticationInitializationUser("user")),
+		},
+		{
+			name:    "with allowed credentials",
+			request: NewAuthenticationInitializationRequest().WithConditionalMediation().WithAllowCredentials(NewCredentialDescriptor("credential")),
+		},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			_, apiErr := client.InitializeAuthentication(tt.request)
+			if !hankoClient.IsValidationError(apiErr.AsError()) {
+				t.Errorf("got %v, want a validation error", apiErr)
+			}
+		})
+	}
+}
+
+func TestHankoApiClient_AuthenticationFinalizationUserHandle(t *testing.T) {
+	var tests = []struct {
+		name       string
+		user       hankoClient.User // the user returned by the API on finalization
+		userHandle string
+		expected   string
+	}{
+		{name: "resolved from the credential", userHandle: "user", expected: "user"},
+		{name: "resolved without user handle", expected: "user"},
+		{name: "forged user handle", userHandle: "victim", expected: "user"},
+		{name: "user handle in another encoding", userHandle: "dXNlcg", expected: "user"},
+		{name: "returned by the API", user: hankoClient.User{ID: "user"}, userHandle: "user", expected: "user"},
+		{name: "forged user handle returned by the API", user: hankoClient.User{ID: "user"}, userHandle: "victim", expected: "user"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				switch {
+				case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/"+string(pathAuthenticationFinalize)):
+					_ = json.NewEncoder(w).Encode(&AuthenticationFinalizationResponse{Credential: Credential{Id: "credential", User: tt.user}})
+				case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/"+string(pathCredentials)+"/credential"):
+					_ = json.NewEncoder(w).Encode(&Credential{Id: "credential", User: hankoClient.User{ID: "user"}})
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			defer ts.Close()
+			client := NewClient(ts.URL, testApiSecret).WithoutLogs()
+
+			requestBody := &AuthenticationFinalizationRequest{}
+			requestBody.AssertionResponse.UserHandle = []byte(tt.userHandle)
+			response, apiErr := client.FinalizeAuthentication(requestBody)
+			if apiErr != nil {
+				t.Fatal(apiErr)
+			}
+			if response.Credential.User.ID != tt.expected {
+				t.Errorf("got user %q, want %q", response.Credential.User.ID, tt.expected)
+			}
+		})
+	}
+}
+
+func TestHankoApiClient_InitializationTimeoutResponse(t *testing.T) {
+	// the timeout is sent to the API, the timeout of the response is kept as returned by the API
+	client := NewClient(testBaseUrl, testApiSecret).WithoutLogs()
+	user := NewAuthenticationInitializationUser("user")
+	requested := uint(time.Minute / time.Millisecond)
+
+	var tests = []struct {
+		name            string
+		apiTimeout      int
+		expectedTimeout int
+	}{
+		{name: "missing", apiTimeout: 0, expectedTimeout: 0},
+		{name: "different", apiTimeout: 120000, expectedTimeout: 120000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registrationRequest := &RegistrationInitializationRequest{}
+			registrationResponse := &RegistrationInitializationResponse{}
+			registrationResponse.Response.Timeout = tt.apiTimeout
+			ts := runTestApi(registrationRequest, registrationResponse, http.StatusOK)
+			ts.Start()
+			registration, apiErr := client.InitializeRegistration(NewRegistrationInitializationRequest(NewRegistrationInitializationUser("user", "name")).WithTimeout(time.Minute))
+			ts.Close()
+			if apiErr != nil {
+				t.Fatal(apiErr)
+			}
+
+			authenticationRequest := &AuthenticationInitializationRequest{}
+			authenticationResponse := &AuthenticationInitializationResponse{}
+			authenticationResponse.Response.Timeout = tt.apiTimeout
+			ts = runTestApi(authenticationRequest, authenticationResponse, http.StatusOK)
+			ts.Start()
+			authentication, apiErr := client.InitializeAuthentication(NewAuthenticationInitializationRequest().WithUser(user).WithTimeout(time.Minute))
+			ts.Close()
+			if apiErr != nil {
+				t.Fatal(apiErr)
+			}
+
+			transactionRequest := &TransactionInitializationRequest{}
+			transactionResponse := &TransactionInitializationResponse{}
+			transactionResponse.Response.Timeout = tt.apiTimeout
+			ts = runTestApi(transactionRequest, transactionResponse, http.StatusOK)
+			ts.Start()
+			transaction, apiErr := client.InitializeTransaction(NewTransactionInitializationRequest(user).WithTimeout(time.Minute))
+			ts.Close()
+			if apiErr != nil {
+				t.Fatal(apiErr)
+			}
+
+			for _, timeout := range []uint{registrationRequest.Options.Timeout, authenticationRequest.Options.Timeout, transactionRequest.Options.Timeout} {
+				if timeout != requested {
+					t.Errorf("got requested timeout %d, want %d", timeout, requested)
+				}
+			}
+			for _, timeout := range []int{registration.Response.Timeout, authentication.Response.Timeout, transaction.Response.Timeout} {
+				if timeout != tt.expectedTimeout {
+					t.Errorf("got timeout %d, want %d", timeout, tt.expectedTimeout)
+				}
+			}
+		})
+	}
+}
+
+func TestHankoApiClient_InitializationWithoutRequestBody(t *testing.T) {
+	client := NewClient(testBaseUrl, testApiSecret).WithoutLogs()
+
+	_, registrationErr := client.InitializeRegistration(nil)
+	_, authenticationErr := client.InitializeAuthentication(nil)
+	_, transactionErr := client.InitializeTransaction(nil)
+	for _, apiErr := range []*hankoClient.ApiError{registrationErr, authenticationErr, transactionErr} {
+		if !hankoClient.IsValidationError(apiErr) {
+			t.Errorf("got %v, want a validation error", apiErr)
+		}
+	}
+}
+
+func TestHankoApiClient_ContextDeadlineExceeded(t *testing.T) {
+	release := make(chan struct{})
+	ts := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-release:
+			case <-r.Context().Done():
+			}
+		}),
+	)
+	defer ts.Close()
+	defer close(release)
+	client := NewClient(ts.URL, testApiSecret).WithoutLogs()
+
+	var tests = []struct {
+		name string
+		call func(ctx context.Context) *hankoClient.ApiError
+	}{
+		{
+			name: "initialize registration",
+			call: func(ctx context.Context) *hankoClient.ApiError {
+				_, err := client.InitializeRegistrationContext(ctx, &RegistrationInitializationRequest{})
+				return err
+			},
+		},
+		{
+			name: "finalize registration",
+			call: func(ctx context.Context) *hankoClient.ApiError {
+				_, err := client.FinalizeRegistrationContext(ctx, &RegistrationFinalizationRequest{})
+				return err
+			},
+		},
+		{
+			name: "initialize authentication",
+			call: func(ctx context.Context) *hankoClient.ApiError {
+				_, err := client.InitializeAuthenticationContext(ctx, &AuthenticationInitializationRequest{})
+				return err
+			},
+		},
+		{
+			name: "finalize authentication",
+			call: func(ctx context.Context) *hankoClient.ApiError {
+				_, err := client.FinalizeAuthenticationContext(ctx, &AuthenticationFinalizationRequest{})
+				return err
+			},
+		},
+		{
+			name: "initialize transaction",
+			call: func(ctx context.Context) *hankoClient.ApiError {
+				_, err := client.InitializeTransactionContext(ctx, &TransactionInitializationRequest{})
+				return err
+			},
+		},
+		{
+			name: "finalize transaction",
+			call: func(ctx context.Context) *hankoClient.ApiError {
+				_, err := client.FinalizeTransactionContext(ctx, &TransactionFinalizationRequest{})
+				return err
+			},
+		},
+		{
+			name: "list credentials",
+			call: func(ctx context.Context) *hankoClient.ApiError {
+				_, err := client.ListCredentialsContext(ctx, &CredentialQuery{})
+				return err
+			},
+		},
+		{
+			name: "get credential",
+			call: func(ctx context.Context) *hankoClient.ApiError {
+				_, err := client.GetCredentialContext(ctx, "test")
+				return err
+			},
+		},
+		{
+			name: "delete credential",
+			call: func(ctx context.Context) *hankoClient.ApiError {
+				return client.DeleteCredentialContext(ctx, "test")
+			},
+		},
+		{
+			name: "update credential",
+			call: func(ctx context.Context) *hankoClient.ApiError {
+				_, err := client.UpdateCredentialContext(ctx, "test", &CredentialUpdateRequest{})
+				return err
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+			start := time.Now()
+			err := tt.call(ctx)
+			if err == nil {
+				t.Error("error expected")
+			}
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Errorf("request did not honour the deadline, took %s", elapsed)
+			}
+		})
+	}
+}
+
+func TestHankoApiClient_WithMiddleware(t *testing.T) {
+	ts := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(&Credential{Id: "test"})
+		}),
+	)
+	defer ts.Close()
+
+	var actions []string
+	client := NewClient(ts.URL, testApiSecret).WithoutLogs().
+		WithMiddleware(func(next hankoClient.Handler) hankoClient.Handler {
+			return func(ctx context.Context, call *hankoClient.Call) *hankoClient.ApiError {
+				actions = append(actions, call.Action)
+				return next(ctx, call)
+			}
+		})
+
+	credential, err := client.GetCredential("test")
+	if err != nil {
+		t.Error(err)
+	}
+	if credential.Id != "test" {
+		t.Errorf("got credential id %s, want test", credential.Id)
+	}
+	if len(actions) != 1 || actions[0] != "get webauthn credential" {
+		t.Errorf("got actions %v, want [get webauthn credential]", actions)
+	}
+}
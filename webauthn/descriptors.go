@@ -0,0 +1,79 @@
+package webauthn
+
+import (
+	"context"
+	"errors"
+)
+
+// AuthenticatorTransport is a hint how the client might communicate with the authenticator of a credential.
+//
+// See also: https://www.w3.org/TR/webauthn-2/#enum-transport
+type AuthenticatorTransport string
+
+const (
+	// Indicates that the authenticator is reachable over USB.
+	TransportUSB AuthenticatorTransport = "usb"
+
+	// Indicates that the authenticator is reachable over Near Field Communication.
+	TransportNFC AuthenticatorTransport = "nfc"
+
+	// Indicates that the authenticator is reachable over Bluetooth Smart.
+	TransportBLE AuthenticatorTransport = "ble"
+
+	// Indicates that the authenticator is a platform authenticator, i.e. part of the client device.
+	TransportInternal AuthenticatorTransport = "internal"
+
+	// Indicates that the authenticator is reachable using a combination of data transport and proximity mechanisms,
+	// e.g. a phone used to authenticate on a desktop computer.
+	TransportHybrid AuthenticatorTransport = "hybrid"
+)
+
+// CredentialDescriptor refers to a credential to be excluded from a registration or allowed for an authentication.
+//
+// See also: https://www.w3.org/TR/webauthn-2/#dictdef-publickeycredentialdescriptor
+type CredentialDescriptor struct {
+	Type       string                   `json:"type"`                 // always "public-key"
+	Id         string                   `json:"id"`                   // the base64url encoded credential ID
+	Transports []AuthenticatorTransport `json:"transports,omitempty"` // hints for the client, may be empty
+}
+
+// NewCredentialDescriptor creates a new CredentialDescriptor for the credential with the given ID, as returned in
+// Credential.Id, and the given AuthenticatorTransport hints.
+func NewCredentialDescriptor(credentialId string, transports ...AuthenticatorTransport) CredentialDescriptor {
+	return CredentialDescriptor{Type: "public-key", Id: credentialId, Transports: transports}
+}
+
+// NewCredentialDescriptors creates a CredentialDescriptor for each of the given credentials. Since the API does not
+// store the transports of a credential, TransportInternal is set for credentials of platform authenticators and no
+// transports for all others.
+func NewCredentialDescriptors(credentials []Credential) []CredentialDescriptor {
+	descriptors := make([]CredentialDescriptor, len(credentials))
+	for i, credential := range credentials {
+		descriptors[i] = NewCredentialDescriptor(credential.Id)
+		if credential.Authenticator != nil && credential.Authenticator.Attachment == string(Platform) {
+			descriptors[i].Transports = []AuthenticatorTransport{TransportInternal}
+		}
+	}
+	return descriptors
+}
+
+// ListCredentialDescriptors returns a CredentialDescriptor for each credential of the user with the given ID, e.g. to
+// exclude them from a registration using RegistrationInitializationRequest.WithExcludeCredentials. Narrow down the
+// credentials using ListAllCredentials and NewCredentialDescriptors instead, e.g. to only allow platform credentials.
+// An empty userId is rejected, as it would return the credentials of all users.
+func (c *Client) ListCredentialDescriptors(userId string) ([]CredentialDescriptor, error) {
+	return c.ListCredentialDescriptorsContext(context.Background(), userId)
+}
+
+// ListCredentialDescriptorsContext is like ListCredentialDescriptors but uses the given context.Context for the
+// requests to the API.
+func (c *Client) ListCredentialDescriptorsContext(ctx context.Context, userId string) ([]CredentialDescriptor, error) {
+	if userId == "" {
+		return nil, errors.New("user id must not be empty")
+	}
+	credentials, err := c.ListAllCredentialsContext(ctx, NewCredentialQuery().WithUserId(userId), 0)
+	if err != nil {
+		return nil, err
+	}
+	return NewCredentialDescriptors(credentials), nil
+}
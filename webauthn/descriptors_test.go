@@ -0,0 +1,56 @@
+package webauthn_test
+
+import (
+	"encoding/json"
+	"github.com/teamhanko/hanko-go/webauthn"
+	"reflect"
+	"testing"
+)
+
+func TestNewCredentialDescriptors(t *testing.T) {
+	descriptors := webauthn.NewCredentialDescriptors([]webauthn.Credential{
+		{Id: "platform", Authenticator: &webauthn.Authenticator{Attachment: string(webauthn.Platform)}},
+		{Id: "cross-platform", Authenticator: &webauthn.Authenticator{Attachment: string(webauthn.CrossPlatform)}},
+		{Id: "unknown"},
+	})
+	expected := []webauthn.CredentialDescriptor{
+		{Type: "public-key", Id: "platform", Transports: []webauthn.AuthenticatorTransport{webauthn.TransportInternal}},
+		{Type: "public-key", Id: "cross-platform"},
+		{Type: "public-key", Id: "unknown"},
+	}
+	if !reflect.DeepEqual(descriptors, expected) {
+		t.Errorf("got %+v, want %+v", descriptors, expected)
+	}
+
+	request := webauthn.NewRegistrationInitializationRequest(webauthn.NewRegistrationInitializationUser("id", "name")).
+		WithExcludeCredentials(webauthn.NewCredentialDescriptor("Y3JlZGVudGlhbA", webauthn.TransportUSB, webauthn.TransportNFC))
+	encoded, err := json.Marshal(request.Options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(encoded) != `{"authenticatorSelection":null,"attestation":"","excludeCredentials":[{"type":"public-key","id":"Y3JlZGVudGlhbA","transports":["usb","nfc"]}]}` {
+		t.Errorf("got %s, want the excluded credential", encoded)
+	}
+}
+
+func TestClient_ListCredentialDescriptors(t *testing.T) {
+	server := newPaginatingServer()
+	defer server.Close()
+	client := webauthn.NewClient(server.URL, testCeremonyApiSecret).WithoutLogs()
+
+	descriptors, err := client.ListCredentialDescriptors("other")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(descriptors) != 3 || descriptors[0].Id != "credential-25" || descriptors[2].Id != "credential-27" {
+		t.Errorf("got %+v, want the descriptors of the 3 credentials of the user", descriptors)
+	}
+
+	requests := len(server.Requests())
+	if _, err = client.ListCredentialDescriptors(""); err == nil {
+		t.Error("expected an error for an empty user id")
+	}
+	if len(server.Requests()) != requests {
+		t.Error("expected no credentials to be listed for an empty user id")
+	}
+}
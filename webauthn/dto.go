@@ -55,8 +55,78 @@ func NewRegistrationInitializationRequest(user RegistrationInitializationUser) *
 // RegistrationInitializationRequestOptions allows you to set additional authenticator attributes for a registration
 // initialization request.
 type RegistrationInitializationRequestOptions struct {
-	AuthenticatorSelection *AuthenticatorSelection `json:"authenticatorSelection"`
-	ConveyancePreference   ConveyancePreference    `json:"attestation"`
+	AuthenticatorSelection *AuthenticatorSelection      `json:"authenticatorSelection"`
+	ConveyancePreference   ConveyancePreference         `json:"attestation"`
+	Extensions             *RegistrationExtensionInputs `json:"extensions,omitempty"`
+	ExcludeCredentials     []CredentialDescriptor       `json:"excludeCredentials,omitempty"`
+	Timeout                uint                         `json:"timeout,omitempty"` // in milliseconds
+}
+
+const (
+	// MinTimeout is the shortest timeout accepted for a ceremony, as recommended by the WebAuthn specification.
+	MinTimeout = 30 * time.Second
+
+	// MaxTimeout is the longest timeout accepted for a ceremony, as recommended by the WebAuthn specification.
+	MaxTimeout = 10 * time.Minute
+)
+
+// WithTimeout allows you to set the time the user has to complete the registration, e.g. a longer time for slow
+// security keys. It is sent to the Hanko Authentication API, which returns the timeout to be passed to the browser in
+// the Timeout of the RegistrationInitializationResponse. The Timeout is returned as set by the API, even if it differs
+// from the requested timeout or is missing. If not set, the API decides on the timeout, which is also the case for a
+// timeout of zero. Any other timeout,
+// including negative ones and ones shorter than a millisecond, must be between MinTimeout and MaxTimeout; otherwise
+// the initialization fails with a validation error before calling the API.
+func (request *RegistrationInitializationRequest) WithTimeout(timeout time.Duration) *RegistrationInitializationRequest {
+	request.Options.Timeout = timeoutMilliseconds(timeout)
+	return request
+}
+
+// timeoutMilliseconds converts the given timeout into the milliseconds of the request options. Timeouts other than
+// zero are converted to at least one millisecond, so that they are not mistaken for an unset timeout.
+func timeoutMilliseconds(timeout time.Duration) uint {
+	if timeout == 0 {
+		return 0
+	}
+	if timeout < time.Millisecond {
+		return 1
+	}
+	return uint(timeout.Milliseconds())
+}
+
+// WithExcludeCredentials allows you to specify credentials that must not be registered again, e.g. using
+// Client.ListCredentialDescriptors. The client refuses to create a credential on an authenticator holding one of them,
+// which prevents registering the same authenticator twice. If not set, the Hanko Authentication API decides which
+// credentials to exclude.
+func (request *RegistrationInitializationRequest) WithExcludeCredentials(descriptors ...CredentialDescriptor) *RegistrationInitializationRequest {
+	request.Options.ExcludeCredentials = descriptors
+	return request
+}
+
+// WithExtensions allows you to request client extensions, e.g. credProps, for the credential registration. The
+// outputs are available through the ClientExtensionResults of the RegistrationFinalizationRequest.
+func (request *RegistrationInitializationRequest) WithExtensions(extensions *RegistrationExtensionInputs) *RegistrationInitializationRequest {
+	request.Options.Extensions = extensions
+	return request
+}
+
+// WithResidentKey allows you to set the ResidentKeyRequirement for the credential registration. See
+// AuthenticatorSelection.WithResidentKey.
+func (request *RegistrationInitializationRequest) WithResidentKey(residentKey ResidentKeyRequirement) *RegistrationInitializationRequest {
+	if request.Options.AuthenticatorSelection == nil {
+		request.Options.AuthenticatorSelection = NewAuthenticatorSelection()
+	}
+	request.Options.AuthenticatorSelection.WithResidentKey(residentKey)
+	return request
+}
+
+// ResidentKeyRequirement returns the effective ResidentKeyRequirement of the options. See
+// AuthenticatorSelection.ResidentKeyRequirement.
+func (options RegistrationInitializationRequestOptions) ResidentKeyRequirement() ResidentKeyRequirement {
+	if options.AuthenticatorSelection == nil {
+		return ResidentKeyDiscouraged
+	}
+	return options.AuthenticatorSelection.ResidentKeyRequirement()
 }
 
 // WithConveyancePreference allows you to set the preferred authenticator attestation ConveyancePreference.
@@ -70,6 +140,7 @@ func (request *RegistrationInitializationRequest) WithConveyancePreference(conve
 type AuthenticatorSelection struct {
 	AuthenticatorAttachment AuthenticatorAttachment     `json:"authenticatorAttachment,omitempty"`
 	RequireResidentKey      bool                        `json:"requireResidentKey,omitempty"`
+	ResidentKey             ResidentKeyRequirement      `json:"residentKey,omitempty"`
 	UserVerification        UserVerificationRequirement `json:"userVerification,omitempty"`
 }
 
@@ -95,11 +166,43 @@ func (authenticatorSelection *AuthenticatorSelection) WithAuthenticatorAttachmen
 // If set to true, the user must use an authenticator device that supports resident keys.
 // If not set, the value will default to "false" and the credential will not be registered
 // as a resident credential.
+//
+// If a ResidentKeyRequirement has been set using WithResidentKey, it is kept consistent: true sets it to
+// ResidentKeyRequired, false changes ResidentKeyRequired to ResidentKeyDiscouraged.
 func (authenticatorSelection *AuthenticatorSelection) WithRequireResidentKey(requireResidentKey bool) *AuthenticatorSelection {
 	authenticatorSelection.RequireResidentKey = requireResidentKey
+	if requireResidentKey && authenticatorSelection.ResidentKey != "" {
+		authenticatorSelection.ResidentKey = ResidentKeyRequired
+	} else if !requireResidentKey && authenticatorSelection.ResidentKey == ResidentKeyRequired {
+		authenticatorSelection.ResidentKey = ResidentKeyDiscouraged
+	}
 	return authenticatorSelection
 }
 
+// WithResidentKey allows you to specify to what extent a client-side discoverable credential (a resident credential,
+// e.g. a passkey) should be created. Use ResidentKeyPreferred to create discoverable credentials where the
+// authenticator supports them without excluding other authenticators.
+//
+// RequireResidentKey is set accordingly for clients only supporting WebAuthn Level 1: it is true if, and only if,
+// the requirement is ResidentKeyRequired.
+func (authenticatorSelection *AuthenticatorSelection) WithResidentKey(residentKey ResidentKeyRequirement) *AuthenticatorSelection {
+	authenticatorSelection.ResidentKey = residentKey
+	authenticatorSelection.RequireResidentKey = residentKey == ResidentKeyRequired
+	return authenticatorSelection
+}
+
+// ResidentKeyRequirement returns the effective ResidentKeyRequirement: the ResidentKey if set, otherwise
+// ResidentKeyRequired if RequireResidentKey is true and ResidentKeyDiscouraged if not.
+func (authenticatorSelection *AuthenticatorSelection) ResidentKeyRequirement() ResidentKeyRequirement {
+	if authenticatorSelection.ResidentKey != "" {
+		return authenticatorSelection.ResidentKey
+	}
+	if authenticatorSelection.RequireResidentKey {
+		return ResidentKeyRequired
+	}
+	return ResidentKeyDiscouraged
+}
+
 // WithUserVerification allows you to set your UserVerificationRequirement for the credential registration.
 // If not set, the value will default to "preferred" (VerificationPreferred) during registration.
 func (authenticatorSelection *AuthenticatorSelection) WithUserVerification(userVerificationRequirement UserVerificationRequirement) *AuthenticatorSelection {
@@ -112,8 +215,65 @@ func (authenticatorSelection *AuthenticatorSelection) WithUserVerification(userV
 // to create a credential.
 //
 // See also: https://www.w3.org/TR/webauthn/#sctn-credentialcreationoptions-extension
+//
+// The WebAuthn Level 2 residentKey member of the authenticatorSelection is not part of protocol.CredentialCreation and
+// is available through ResidentKey instead. It is kept when the response is encoded to JSON. Like the Timeout, it is
+// set as returned by the API and not taken from the RegistrationInitializationRequest, so it is empty if the API only
+// returned the WebAuthn Level 1 requireResidentKey member.
 type RegistrationInitializationResponse struct {
 	protocol.CredentialCreation
+	ResidentKey ResidentKeyRequirement `json:"-"`
+}
+
+// MarshalJSON encodes the RegistrationInitializationResponse, adding the ResidentKey to the authenticatorSelection.
+func (response RegistrationInitializationResponse) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(response.CredentialCreation)
+	if err != nil || response.ResidentKey == "" {
+		return data, err
+	}
+	creation := map[string]json.RawMessage{}
+	publicKey := map[string]json.RawMessage{}
+	selection := map[string]json.RawMessage{}
+	if err = json.Unmarshal(data, &creation); err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(creation["publicKey"], &publicKey); err != nil {
+		return nil, err
+	}
+	if publicKey["authenticatorSelection"] != nil {
+		if err = json.Unmarshal(publicKey["authenticatorSelection"], &selection); err != nil {
+			return nil, err
+		}
+	}
+	if selection["residentKey"], err = json.Marshal(response.ResidentKey); err != nil {
+		return nil, err
+	}
+	if publicKey["authenticatorSelection"], err = json.Marshal(selection); err != nil {
+		return nil, err
+	}
+	if creation["publicKey"], err = json.Marshal(publicKey); err != nil {
+		return nil, err
+	}
+	return json.Marshal(creation)
+}
+
+// UnmarshalJSON decodes a RegistrationInitializationResponse, including the ResidentKey of the authenticatorSelection.
+func (response *RegistrationInitializationResponse) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &response.CredentialCreation); err != nil {
+		return err
+	}
+	residentKey := struct {
+		PublicKey struct {
+			AuthenticatorSelection struct {
+				ResidentKey ResidentKeyRequirement `json:"residentKey"`
+			} `json:"authenticatorSelection"`
+		} `json:"publicKey"`
+	}{}
+	if err := json.Unmarshal(data, &residentKey); err != nil {
+		return err
+	}
+	response.ResidentKey = residentKey.PublicKey.AuthenticatorSelection.ResidentKey
+	return nil
 }
 
 // RegistrationFinalizationRequest contains the representation of a PublicKeyCredential obtained through credential
@@ -122,6 +282,9 @@ type RegistrationInitializationResponse struct {
 // See also: https://www.w3.org/TR/webauthn-2/#publickeycredential
 type RegistrationFinalizationRequest struct {
 	protocol.CredentialCreationResponse
+
+	// The outputs of the requested client extensions, as returned by getClientExtensionResults().
+	ClientExtensionResults *ClientExtensionOutputs `json:"clientExtensionResults,omitempty"`
 }
 
 // ParseRegistrationFinalizationRequest decodes the content of the specified io.Reader into a
@@ -136,6 +299,21 @@ func ParseRegistrationFinalizationRequest(requestBody io.Reader) (request *Regis
 // RegistrationFinalizationResponse is the response when the credential registration was successful.
 type RegistrationFinalizationResponse struct {
 	Credential Credential `json:"credential"`
+
+	// The outputs of the requested client extensions. Set to those of the RegistrationFinalizationRequest if not
+	// returned by the API.
+	ClientExtensionResults *ClientExtensionOutputs `json:"clientExtensionResults,omitempty"`
+}
+
+// IsDiscoverable reports whether the registered credential is a client-side discoverable credential. The output of the
+// credProps extension (see RegistrationExtensionInputs.WithCredProps) takes precedence if the client reported it,
+// as the API cannot know whether the authenticator created a discoverable credential for ResidentKeyPreferred.
+// Otherwise, Credential.IsResidentKey is returned.
+func (response *RegistrationFinalizationResponse) IsDiscoverable() bool {
+	if outputs := response.ClientExtensionResults; outputs != nil && outputs.CredProps != nil && outputs.CredProps.ResidentKey != nil {
+		return *outputs.CredProps.ResidentKey
+	}
+	return response.Credential.IsResidentKey
 }
 
 // Authenticator holds information about the authenticator associated with a registered credential.
@@ -155,6 +333,10 @@ type Authenticator struct {
 type AuthenticationInitializationRequest struct {
 	User    hankoClient.User                           `json:"user"`
 	Options AuthenticationInitializationRequestOptions `json:"options"`
+
+	// The mediation to be used by the browser. It is not sent to the Hanko Authentication API but returned in the
+	// AuthenticationInitializationResponse.
+	Mediation CredentialMediationRequirement `json:"-"`
 }
 
 // NewAuthenticationInitializationRequest creates a new AuthenticationInitializationRequest.
@@ -170,11 +352,47 @@ func (request *AuthenticationInitializationRequest) WithUser(user Authentication
 	return request
 }
 
+// WithConditionalMediation initializes an authentication for conditional mediation (passkey autofill): no user is set
+// and no credentials are allowed explicitly, so that the user can choose any of their discoverable credentials in the
+// autofill of the browser. The AuthenticationInitializationResponse contains the MediationConditional to be passed to
+// navigator.credentials.get(). On finalization, the user of the credential is available through the Credential of
+// the AuthenticationFinalizationResponse (see Client.FinalizeAuthentication).
+func (request *AuthenticationInitializationRequest) WithConditionalMediation() *AuthenticationInitializationRequest {
+	request.User = hankoClient.User{}
+	request.Options.AllowCredentials = nil
+	request.Mediation = MediationConditional
+	return request
+}
+
 // AuthenticationInitializationRequestOptions allows you to set additional authenticator attributes for the
 // authentication initialization.
 type AuthenticationInitializationRequestOptions struct {
-	UserVerification        UserVerificationRequirement `json:"userVerification"`
-	AuthenticatorAttachment AuthenticatorAttachment     `json:"authenticatorAttachment"`
+	UserVerification        UserVerificationRequirement    `json:"userVerification"`
+	AuthenticatorAttachment AuthenticatorAttachment        `json:"authenticatorAttachment"`
+	Extensions              *AuthenticationExtensionInputs `json:"extensions,omitempty"`
+	AllowCredentials        []CredentialDescriptor         `json:"allowCredentials,omitempty"`
+	Timeout                 uint                           `json:"timeout,omitempty"` // in milliseconds
+}
+
+// WithTimeout allows you to set the time the user has to complete the authentication. See
+// RegistrationInitializationRequest.WithTimeout.
+func (request *AuthenticationInitializationRequest) WithTimeout(timeout time.Duration) *AuthenticationInitializationRequest {
+	request.Options.Timeout = timeoutMilliseconds(timeout)
+	return request
+}
+
+// WithAllowCredentials allows you to restrict the credentials which may be used for the authentication, e.g. to the
+// platform credentials of the user. If not set, all credentials of the user are allowed.
+func (request *AuthenticationInitializationRequest) WithAllowCredentials(descriptors ...CredentialDescriptor) *AuthenticationInitializationRequest {
+	request.Options.AllowCredentials = descriptors
+	return request
+}
+
+// WithExtensions allows you to request client extensions, e.g. appid or prf, for the authentication. The outputs are
+// available through the ClientExtensionResults of the AuthenticationFinalizationRequest.
+func (request *AuthenticationInitializationRequest) WithExtensions(extensions *AuthenticationExtensionInputs) *AuthenticationInitializationRequest {
+	request.Options.Extensions = extensions
+	return request
 }
 
 // WithAuthenticatorAttachment allows you to set the AuthenticatorAttachment modality for the authentication
@@ -198,6 +416,9 @@ func (request *AuthenticationInitializationRequest) WithUserVerification(userVer
 // See also: https://www.w3.org/TR/webauthn-2/#sctn-credentialrequestoptions-extension
 type AuthenticationInitializationResponse struct {
 	protocol.CredentialAssertion
+
+	// The mediation requested using AuthenticationInitializationRequest.WithConditionalMediation, if any.
+	Mediation CredentialMediationRequirement `json:"mediation,omitempty"`
 }
 
 // AuthenticationFinalizationRequest contains the representation of a PublicKeyCredential obtained through assertion
@@ -206,6 +427,9 @@ type AuthenticationInitializationResponse struct {
 // See also: https://www.w3.org/TR/webauthn-2/#publickeycredential
 type AuthenticationFinalizationRequest struct {
 	protocol.CredentialAssertionResponse
+
+	// The outputs of the requested client extensions, as returned by getClientExtensionResults().
+	ClientExtensionResults *ClientExtensionOutputs `json:"clientExtensionResults,omitempty"`
 }
 
 // ParseAuthenticationFinalizationRequest decodes the content of the specified io.Reader into a
@@ -220,6 +444,10 @@ func ParseAuthenticationFinalizationRequest(reader io.Reader) (request *Authenti
 // AuthenticationFinalizationResponse is the response when the authentication was successful.
 type AuthenticationFinalizationResponse struct {
 	Credential Credential `json:"credential"`
+
+	// The outputs of the requested client extensions. Set to those of the AuthenticationFinalizationRequest if not
+	// returned by the API.
+	ClientExtensionResults *ClientExtensionOutputs `json:"clientExtensionResults,omitempty"`
 }
 
 // TransactionInitializationRequest is used to initialize a transaction operation.
@@ -257,6 +485,27 @@ func (request *TransactionInitializationRequest) WithUserVerification(userVerifi
 	return request
 }
 
+// WithExtensions allows you to request client extensions for the transaction. See
+// AuthenticationInitializationRequest.WithExtensions.
+func (request *TransactionInitializationRequest) WithExtensions(extensions *AuthenticationExtensionInputs) *TransactionInitializationRequest {
+	request.Options.Extensions = extensions
+	return request
+}
+
+// WithTimeout allows you to set the time the user has to confirm the transaction. See
+// RegistrationInitializationRequest.WithTimeout.
+func (request *TransactionInitializationRequest) WithTimeout(timeout time.Duration) *TransactionInitializationRequest {
+	request.Options.Timeout = timeoutMilliseconds(timeout)
+	return request
+}
+
+// WithAllowCredentials allows you to restrict the credentials which may be used to confirm the transaction. See
+// AuthenticationInitializationRequest.WithAllowCredentials.
+func (request *TransactionInitializationRequest) WithAllowCredentials(descriptors ...CredentialDescriptor) *TransactionInitializationRequest {
+	request.Options.AllowCredentials = descriptors
+	return request
+}
+
 // TransactionInitializationResponse contains the representation of CredentialRequestOptions generated by the Hanko
 // Authentication API that must be passed to browser's WebAuthn API via navigator.credentials.get() in order
 // to authenticate with a credential/create an assertion.
@@ -280,10 +529,27 @@ type TransactionFinalizationRequest struct {
 }
 
 // CredentialQuery is used to search for credentials.
+//
+// Only UserId, PageSize and Page are sent to the Hanko Authentication API. All other filters and the sort order are
+// applied by the Client while walking all pages: IterateCredentials filters the credentials but returns them in the
+// order of the API, while ListAllCredentials filters and sorts all credentials. ListCredentials returns a single page
+// and rejects them with a validation error.
 type CredentialQuery struct {
-	UserId   string `json:"user_id" url:"user_id"`     // The user ID to filter by.
-	PageSize uint   `json:"page_size" url:"page_size"` // The page size of the returned result set.
-	Page     uint   `json:"page" url:"page"`           // The desired page to return from the result set.
+	UserId   string `json:"user_id" url:"user_id"`     // The user ID to filter by (server-side).
+	PageSize uint   `json:"page_size" url:"page_size"` // The page size of the returned result set (server-side).
+	Page     uint   `json:"page" url:"page"`           // The desired page to return from the result set (server-side).
+
+	NameContains            string                  `json:"-" url:"-"` // A case-insensitive substring of the name.
+	Aaguid                  string                  `json:"-" url:"-"` // The AAGUID of the authenticator.
+	AuthenticatorAttachment AuthenticatorAttachment `json:"-" url:"-"` // The attachment of the authenticator.
+	IsResidentKey           *bool                   `json:"-" url:"-"` // Whether the credential is a resident key.
+	UserVerification        *bool                   `json:"-" url:"-"` // Whether the credential has been registered with user verification.
+	CreatedAfter            time.Time               `json:"-" url:"-"` // The lower bound (inclusive) of the creation time.
+	CreatedBefore           time.Time               `json:"-" url:"-"` // The upper bound (exclusive) of the creation time.
+	LastUsedAfter           time.Time               `json:"-" url:"-"` // The lower bound (inclusive) of the last use.
+	LastUsedBefore          time.Time               `json:"-" url:"-"` // The upper bound (exclusive) of the last use.
+	SortBy                  CredentialSortField     `json:"-" url:"-"` // The field to sort by; unsorted if empty.
+	SortDescending          bool                    `json:"-" url:"-"` // Whether to sort in descending order.
 }
 
 // NewCredentialQuery creates a new CredentialQuery that can be used to filter and paginate results when
@@ -310,6 +576,63 @@ func (c *CredentialQuery) WithPage(page uint) *CredentialQuery {
 	return c
 }
 
+// WithNameContains allows you to filter credentials whose name contains the given string, ignoring case. Applied
+// client-side.
+func (c *CredentialQuery) WithNameContains(name string) *CredentialQuery {
+	c.NameContains = name
+	return c
+}
+
+// WithAaguid allows you to filter credentials by the AAGUID of their authenticator. Applied client-side.
+func (c *CredentialQuery) WithAaguid(aaguid string) *CredentialQuery {
+	c.Aaguid = aaguid
+	return c
+}
+
+// WithAuthenticatorAttachment allows you to filter credentials by the attachment of their authenticator. Applied
+// client-side.
+func (c *CredentialQuery) WithAuthenticatorAttachment(authenticatorAttachment AuthenticatorAttachment) *CredentialQuery {
+	c.AuthenticatorAttachment = authenticatorAttachment
+	return c
+}
+
+// WithResidentKey allows you to filter resident (discoverable) or non-resident credentials. Applied client-side.
+func (c *CredentialQuery) WithResidentKey(isResidentKey bool) *CredentialQuery {
+	c.IsResidentKey = &isResidentKey
+	return c
+}
+
+// WithUserVerification allows you to filter credentials by whether they have been registered with user verification.
+// Applied client-side.
+func (c *CredentialQuery) WithUserVerification(userVerification bool) *CredentialQuery {
+	c.UserVerification = &userVerification
+	return c
+}
+
+// WithCreatedBetween allows you to filter credentials created at or after the given time and before the given end.
+// Pass a zero time to leave a bound open. Applied client-side.
+func (c *CredentialQuery) WithCreatedBetween(after time.Time, before time.Time) *CredentialQuery {
+	c.CreatedAfter = after
+	c.CreatedBefore = before
+	return c
+}
+
+// WithLastUsedBetween allows you to filter credentials last used at or after the given time and before the given end.
+// Pass a zero time to leave a bound open. Credentials that have never been used have a zero LastUsed, so they match
+// an open lower bound only. Applied client-side.
+func (c *CredentialQuery) WithLastUsedBetween(after time.Time, before time.Time) *CredentialQuery {
+	c.LastUsedAfter = after
+	c.LastUsedBefore = before
+	return c
+}
+
+// WithSort allows you to sort credentials by the given CredentialSortField. Applied client-side.
+func (c *CredentialQuery) WithSort(sortBy CredentialSortField, descending bool) *CredentialQuery {
+	c.SortBy = sortBy
+	c.SortDescending = descending
+	return c
+}
+
 // Credential represents a credential.
 type Credential struct {
 	// ID of the credential.
@@ -328,7 +651,11 @@ type Credential struct {
 	// Indicates whether this credential was registered with a successful user verification process.
 	UserVerification bool `json:"userVerification"`
 
-	// Indicates whether this credential was registered as a resident credential/client-side discoverable credential.
+	// Indicates whether this credential was registered as a resident credential/client-side discoverable credential,
+	// e.g. a passkey. Discoverable credentials can be used for authentication without a user. This is the
+	// discoverability known to the API, which only learns it from a ResidentKeyRequired registration. For registrations
+	// with ResidentKeyPreferred, only the client knows the outcome; it is reported once, on registration, through
+	// RegistrationFinalizationResponse.IsDiscoverable, and should be stored by the caller if needed later.
 	IsResidentKey bool `json:"isResidentKey"`
 
 	// Representation of the authenticator used for registering the credential.
@@ -369,6 +696,26 @@ const (
 	CrossPlatform AuthenticatorAttachment = "cross-platform"
 )
 
+// ResidentKeyRequirement describes the relying party's requirements for client-side discoverable credentials
+// (WebAuthn Level 2).
+//
+// See also: https://www.w3.org/TR/webauthn-2/#enum-residentKeyRequirement
+type ResidentKeyRequirement string
+
+const (
+	// Indicates that the relying party prefers creating a server-side credential, but will accept a client-side
+	// discoverable credential.
+	ResidentKeyDiscouraged ResidentKeyRequirement = "discouraged"
+
+	// Indicates that the relying party strongly prefers creating a client-side discoverable credential, but will
+	// accept a server-side credential.
+	ResidentKeyPreferred ResidentKeyRequirement = "preferred"
+
+	// Indicates that the relying party requires a client-side discoverable credential. The registration fails if
+	// the authenticator cannot create one.
+	ResidentKeyRequired ResidentKeyRequirement = "required"
+)
+
 // A WebAuthn relying party may require user verification for some of its operations but not for others, and may use
 // this type to express its needs.
 //
@@ -408,3 +755,35 @@ const (
 	// authenticator.
 	PreferDirectAttestation ConveyancePreference = "direct"
 )
+
+// CredentialMediationRequirement describes how the browser mediates between the user and the request for a credential.
+//
+// See also: https://w3c.github.io/webappsec-credential-management/#mediation-requirements
+type CredentialMediationRequirement string
+
+const (
+	// Indicates that the browser shows the credential selection dialog immediately. This is the default.
+	MediationOptional CredentialMediationRequirement = "optional"
+
+	// Indicates that the browser offers the discoverable credentials of the user through the autofill of input fields
+	// annotated with autocomplete="username webauthn" instead of showing a dialog (passkey autofill).
+	MediationConditional CredentialMediationRequirement = "conditional"
+
+	// Indicates that the browser always requires the user to select a credential.
+	MediationRequired CredentialMediationRequirement = "required"
+)
+
+// CredentialSortField is a field of a Credential that credentials can be sorted by using CredentialQuery.WithSort.
+type CredentialSortField string
+
+const (
+	// Sorts credentials by the time of their creation.
+	SortByCreatedAt CredentialSortField = "createdAt"
+
+	// Sorts credentials by the time of their last use. Credentials that have never been used come first in ascending
+	// order.
+	SortByLastUsed CredentialSortField = "lastUsed"
+
+	// Sorts credentials by their name.
+	SortByName CredentialSortField = "name"
+)
@@ -1,8 +1,10 @@
 package webauthn
 
 import (
+	"encoding/json"
 	"github.com/teamhanko/hanko-go/client"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -205,6 +207,18 @@ func TestWebauthn_NewAuthenticationInitializationRequest(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "init object with conditional mediation",
+			test: NewAuthenticationInitializationRequest().WithUser(NewAuthenticationInitializationUser("id")).
+				WithAllowCredentials(NewCredentialDescriptor("credential")).WithUserVerification(VerificationRequired).
+				WithConditionalMediation(),
+			expected: &AuthenticationInitializationRequest{
+				Options: AuthenticationInitializationRequestOptions{
+					UserVerification: VerificationRequired,
+				},
+				Mediation: MediationConditional,
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -321,3 +335,182 @@ func TestWebauthn_NewCredentialUpdateRequest(t *testing.T) {
 		})
 	}
 }
+
+func TestAuthenticatorSelection_ResidentKey(t *testing.T) {
+	var tests = []struct {
+		name                       string
+		test                       *AuthenticatorSelection
+		expectedResidentKey        ResidentKeyRequirement
+		expectedRequireResidentKey bool
+		expectedRequirement        ResidentKeyRequirement
+	}{
+		{
+			name:                "not set",
+			test:                NewAuthenticatorSelection(),
+			expectedRequirement: ResidentKeyDiscouraged,
+		},
+		{
+			name:                       "level 1 only",
+			test:                       NewAuthenticatorSelection().WithRequireResidentKey(true),
+			expectedRequireResidentKey: true,
+			expectedRequirement:        ResidentKeyRequired,
+		},
+		{
+			name:                "preferred",
+			test:                NewAuthenticatorSelection().WithResidentKey(ResidentKeyPreferred),
+			expectedResidentKey: ResidentKeyPreferred,
+			expectedRequirement: ResidentKeyPreferred,
+		},
+		{
+			name:                       "required",
+			test:                       NewAuthenticatorSelection().WithRequireResidentKey(false).WithResidentKey(ResidentKeyRequired),
+			expectedResidentKey:        ResidentKeyRequired,
+			expectedRequireResidentKey: true,
+			expectedRequirement:        ResidentKeyRequired,
+		},
+		{
+			name:                       "require resident key overrides preferred",
+			test:                       NewAuthenticatorSelection().WithResidentKey(ResidentKeyPreferred).WithRequireResidentKey(true),
+			expectedResidentKey:        ResidentKeyRequired,
+			expectedRequireResidentKey: true,
+			expectedRequirement:        ResidentKeyRequired,
+		},
+		{
+			name:                "not requiring a resident key overrides required",
+			test:                NewAuthenticatorSelection().WithResidentKey(ResidentKeyRequired).WithRequireResidentKey(false),
+			expectedResidentKey: ResidentKeyDiscouraged,
+			expectedRequirement: ResidentKeyDiscouraged,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.test.ResidentKey != tt.expectedResidentKey || tt.test.RequireResidentKey != tt.expectedRequireResidentKey {
+				t.Errorf("got %q and %t, want %q and %t", tt.test.ResidentKey, tt.test.RequireResidentKey, tt.expectedResidentKey, tt.expectedRequireResidentKey)
+			}
+			if requirement := tt.test.ResidentKeyRequirement(); requirement != tt.expectedRequirement {
+				t.Errorf("got requirement %q, want %q", requirement, tt.expectedRequirement)
+			}
+		})
+	}
+
+	request := NewRegistrationInitializationRequest(NewRegistrationInitializationUser("id", "name"))
+	if requirement := request.Options.ResidentKeyRequirement(); requirement != ResidentKeyDiscouraged {
+		t.Errorf("got requirement %q, want %q", requirement, ResidentKeyDiscouraged)
+	}
+	if requirement := request.WithResidentKey(ResidentKeyPreferred).Options.ResidentKeyRequirement(); requirement != ResidentKeyPreferred {
+		t.Errorf("got requirement %q, want %q", requirement, ResidentKeyPreferred)
+	}
+}
+
+func TestRegistrationFinalizationResponse_IsDiscoverable(t *testing.T) {
+	yes, no := true, false
+	var tests = []struct {
+		name          string
+		isResidentKey bool
+		credProps     *CredentialPropertiesOutput
+		expected      bool
+	}{
+		{name: "resident key without credProps", isResidentKey: true, expected: true},
+		{name: "no resident key without credProps", isResidentKey: false, expected: false},
+		{name: "credProps reports discoverable", isResidentKey: false, credProps: &CredentialPropertiesOutput{ResidentKey: &yes}, expected: true},
+		{name: "credProps reports not discoverable", isResidentKey: true, credProps: &CredentialPropertiesOutput{ResidentKey: &no}, expected: false},
+		{name: "credProps without rk", isResidentKey: true, credProps: &CredentialPropertiesOutput{}, expected: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := &RegistrationFinalizationResponse{Credential: Credential{IsResidentKey: tt.isResidentKey}}
+			if tt.credProps != nil {
+				response.ClientExtensionResults = &ClientExtensionOutputs{CredProps: tt.credProps}
+			}
+			if discoverable := response.IsDiscoverable(); discoverable != tt.expected {
+				t.Errorf("got %t, want %t", discoverable, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRegistrationInitializationResponse_ResidentKey(t *testing.T) {
+	data := []byte(`{"publicKey":{"challenge":"Y2hhbGxlbmdl","authenticatorSelection":{"requireResidentKey":false,"residentKey":"preferred"}}}`)
+	response := &RegistrationInitializationResponse{}
+	if err := json.Unmarshal(data, response); err != nil {
+		t.Fatal(err)
+	}
+	if response.ResidentKey != ResidentKeyPreferred {
+		t.Errorf("got %q, want %q", response.ResidentKey, ResidentKeyPreferred)
+	}
+
+	encoded, err := json.Marshal(response)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := struct {
+		PublicKey struct {
+			Challenge              string                 `json:"challenge"`
+			AuthenticatorSelection map[string]interface{} `json:"authenticatorSelection"`
+		} `json:"publicKey"`
+	}{}
+	if err = json.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.PublicKey.Challenge != "Y2hhbGxlbmdl" || decoded.PublicKey.AuthenticatorSelection["residentKey"] != "preferred" {
+		t.Errorf("got %s, want the challenge and the resident key requirement to be kept", encoded)
+	}
+}
+
+func TestExtensionInputs_JSON(t *testing.T) {
+	var tests = []struct {
+		name     string
+		test     interface{}
+		expected string
+	}{
+		{
+			name:     "no extensions",
+			test:     NewRegistrationInitializationRequest(NewRegistrationInitializationUser("id", "name")).Options,
+			expected: `{"authenticatorSelection":null,"attestation":""}`,
+		},
+		{
+			name: "registration",
+			test: NewRegistrationExtensionInputs().WithCredProps().WithLargeBlob(LargeBlobPreferred).
+				WithPRF(nil).WithCredProtect(UserVerificationRequired, true),
+			expected: `{"credProps":true,"largeBlob":{"support":"preferred"},"prf":{},` +
+				`"credentialProtectionPolicy":"userVerificationRequired","enforceCredentialProtectionPolicy":true}`,
+		},
+		{
+			name: "authentication",
+			test: NewAuthenticationExtensionInputs().WithAppID("https://example.com/app-id.json").
+				WithPRF(PRFValues{First: []byte("first")}).
+				WithPRFByCredential("Y3JlZGVudGlhbA", PRFValues{First: []byte("first"), Second: []byte("second")}),
+			expected: `{"appid":"https://example.com/app-id.json","prf":{"eval":{"first":"Zmlyc3Q"},` +
+				`"evalByCredential":{"Y3JlZGVudGlhbA":{"first":"Zmlyc3Q","second":"c2Vjb25k"}}}}`,
+		},
+		{
+			name:     "large blob write",
+			test:     NewAuthenticationExtensionInputs().WithLargeBlobWrite([]byte("blob")),
+			expected: `{"largeBlob":{"write":"YmxvYg"}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded, err := json.Marshal(tt.test)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(encoded) != tt.expected {
+				t.Errorf("got %s, want %s", encoded, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseAuthenticationFinalizationRequest_ClientExtensionResults(t *testing.T) {
+	body := `{"id":"Y3JlZGVudGlhbA","rawId":"Y3JlZGVudGlhbA","type":"public-key","response":{},` +
+		`"clientExtensionResults":{"appid":true,"prf":{"results":{"first":"Zmlyc3Q"}}}}`
+	request, err := ParseAuthenticationFinalizationRequest(strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	outputs := request.ClientExtensionResults
+	if outputs == nil || outputs.AppID == nil || !*outputs.AppID || outputs.PRF == nil || string(outputs.PRF.Results.First) != "first" {
+		t.Errorf("got %+v, want the appid and prf outputs", outputs)
+	}
+}
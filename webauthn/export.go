@@ -0,0 +1,329 @@
+package webauthn
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	hankoClient "github.com/teamhanko/hanko-go/client"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ExportFormat is the file format of a credential export.
+type ExportFormat string
+
+const (
+	// JSON Lines: one JSON encoded Credential per line.
+	FormatJSONLines ExportFormat = "jsonl"
+
+	// CSV with a header row, one Credential per row. See CSVHeader for the columns.
+	FormatCSV ExportFormat = "csv"
+)
+
+// ErrUnknownFormat is returned when creating a CredentialWriter or CredentialReader for an unknown ExportFormat.
+var ErrUnknownFormat = errors.New("unknown export format")
+
+// CSVHeader contains the columns of a credential export in FormatCSV. Times are formatted as defined by RFC 3339; a
+// credential that has never been used has an empty last_used column. Text values starting with a character that makes
+// spreadsheet applications evaluate them as a formula (=, +, -, @, tab or carriage return) or with a single quote are
+// prefixed with a single quote. A CredentialReader removes the prefix again.
+var CSVHeader = []string{
+	"id",
+	"name",
+	"created_at",
+	"last_used",
+	"user_verification",
+	"is_resident_key",
+	"authenticator_aaguid",
+	"authenticator_name",
+	"authenticator_attachment",
+	"user_id",
+	"user_name",
+	"user_display_name",
+}
+
+// CredentialWriter writes credentials to an io.Writer in an ExportFormat. Writes may be buffered; call Flush after the
+// last Credential has been written.
+type CredentialWriter struct {
+	format ExportFormat
+	json   *json.Encoder
+	csv    *csv.Writer
+	header bool
+}
+
+// NewCredentialWriter creates a new CredentialWriter writing to w in the given ExportFormat.
+func NewCredentialWriter(w io.Writer, format ExportFormat) (*CredentialWriter, error) {
+	switch format {
+	case FormatJSONLines:
+		return &CredentialWriter{format: format, json: json.NewEncoder(w)}, nil
+	case FormatCSV:
+		return &CredentialWriter{format: format, csv: csv.NewWriter(w)}, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownFormat, format)
+	}
+}
+
+// Write writes the given Credential. In FormatCSV, the header is written before the first Credential.
+func (w *CredentialWriter) Write(credential Credential) error {
+	if w.format == FormatJSONLines {
+		return w.json.Encode(credential)
+	}
+	if !w.header {
+		if err := w.csv.Write(CSVHeader); err != nil {
+			return err
+		}
+		w.header = true
+	}
+	return w.csv.Write(credentialToRecord(credential))
+}
+
+// Flush writes any buffered data to the underlying io.Writer. In FormatCSV, the header is written if no Credential
+// has been written, so that an empty export can be read again.
+func (w *CredentialWriter) Flush() error {
+	if w.format == FormatJSONLines {
+		return nil
+	}
+	if !w.header {
+		if err := w.csv.Write(CSVHeader); err != nil {
+			return err
+		}
+		w.header = true
+	}
+	w.csv.Flush()
+	return w.csv.Error()
+}
+
+// ExportCredentials writes all credentials matching the given CredentialQuery to w in the given ExportFormat and
+// returns the number of credentials written. The credentials are streamed page by page using a CredentialIterator,
+// so the export is not held in memory; the sort order of the CredentialQuery is therefore ignored.
+func (c *Client) ExportCredentials(w io.Writer, format ExportFormat, credentialQuery *CredentialQuery) (int, error) {
+	return c.ExportCredentialsContext(context.Background(), w, format, credentialQuery)
+}
+
+// ExportCredentialsContext is like ExportCredentials but uses the given context.Context for the requests to the API.
+func (c *Client) ExportCredentialsContext(ctx context.Context, w io.Writer, format ExportFormat, credentialQuery *CredentialQuery) (int, error) {
+	writer, err := NewCredentialWriter(w, format)
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	it := c.IterateCredentialsContext(ctx, credentialQuery)
+	for it.Next() {
+		if err = writer.Write(it.Credential()); err != nil {
+			return count, err
+		}
+		count++
+	}
+	if err = it.Err(); err != nil {
+		return count, err
+	}
+	return count, writer.Flush()
+}
+
+// CredentialReader reads credentials written by a CredentialWriter from an io.Reader.
+type CredentialReader struct {
+	format  ExportFormat
+	lines   *bufio.Reader
+	line    int
+	csv     *csv.Reader
+	columns map[string]int
+}
+
+// NewCredentialReader creates a new CredentialReader reading from r in the given ExportFormat.
+func NewCredentialReader(r io.Reader, format ExportFormat) (*CredentialReader, error) {
+	switch format {
+	case FormatJSONLines:
+		return &CredentialReader{format: format, lines: bufio.NewReader(r)}, nil
+	case FormatCSV:
+		reader := csv.NewReader(r)
+		reader.ReuseRecord = true
+		return &CredentialReader{format: format, csv: reader}, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownFormat, format)
+	}
+}
+
+// Read reads the next Credential. It returns io.EOF when there are no more credentials. Errors of malformed input
+// contain the line number.
+func (r *CredentialReader) Read() (Credential, error) {
+	if r.format == FormatJSONLines {
+		return r.readJSON()
+	}
+	return r.readCSV()
+}
+
+func (r *CredentialReader) readJSON() (Credential, error) {
+	for {
+		line, err := r.lines.ReadBytes('\n')
+		if len(line) == 0 && err != nil {
+			return Credential{}, err
+		}
+		r.line++
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		credential := Credential{}
+		if err = json.Unmarshal(line, &credential); err != nil {
+			return Credential{}, fmt.Errorf("line %d: %w", r.line, err)
+		}
+		return credential, nil
+	}
+}
+
+func (r *CredentialReader) readCSV() (Credential, error) {
+	if r.columns == nil {
+		header, err := r.csv.Read()
+		if err == io.EOF {
+			return Credential{}, fmt.Errorf("missing header: %w", io.ErrUnexpectedEOF)
+		}
+		if err != nil {
+			return Credential{}, err
+		}
+		r.columns = map[string]int{}
+		for i, column := range header {
+			r.columns[column] = i
+		}
+		if _, ok := r.columns["id"]; !ok {
+			return Credential{}, errors.New("missing id column in header")
+		}
+	}
+	record, err := r.csv.Read()
+	if err != nil {
+		return Credential{}, err
+	}
+	line, _ := r.csv.FieldPos(0)
+	credential, err := r.recordToCredential(record)
+	if err != nil {
+		return Credential{}, fmt.Errorf("line %d: %w", line, err)
+	}
+	return credential, nil
+}
+
+// ReadCredentials reads all credentials from r in the given ExportFormat.
+func ReadCredentials(r io.Reader, format ExportFormat) ([]Credential, error) {
+	reader, err := NewCredentialReader(r, format)
+	if err != nil {
+		return nil, err
+	}
+	credentials := []Credential{}
+	for {
+		credential, err := reader.Read()
+		if err == io.EOF {
+			return credentials, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		credentials = append(credentials, credential)
+	}
+}
+
+// csvEscapePrefix is prefixed to text values of a CSV export that would otherwise be evaluated as a formula.
+const csvEscapePrefix = "'"
+
+// escapeCSV prefixes the given value with the csvEscapePrefix if it starts with a character triggering formula
+// evaluation in spreadsheet applications, or with the csvEscapePrefix itself so that unescaping is unambiguous.
+func escapeCSV(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r"+csvEscapePrefix, rune(value[0])) {
+		return csvEscapePrefix + value
+	}
+	return value
+}
+
+// unescapeCSV removes the prefix added by escapeCSV.
+func unescapeCSV(value string) string {
+	return strings.TrimPrefix(value, csvEscapePrefix)
+}
+
+func credentialToRecord(credential Credential) []string {
+	lastUsed := ""
+	if !credential.LastUsed.IsZero() {
+		lastUsed = credential.LastUsed.Format(time.RFC3339Nano)
+	}
+	authenticator := Authenticator{}
+	if credential.Authenticator != nil {
+		authenticator = *credential.Authenticator
+	}
+	return []string{
+		escapeCSV(credential.Id),
+		escapeCSV(credential.Name),
+		credential.CreatedAt.Format(time.RFC3339Nano),
+		lastUsed,
+		strconv.FormatBool(credential.UserVerification),
+		strconv.FormatBool(credential.IsResidentKey),
+		escapeCSV(authenticator.Aaguid),
+		escapeCSV(authenticator.Name),
+		escapeCSV(authenticator.Attachment),
+		escapeCSV(credential.User.ID),
+		escapeCSV(credential.User.Name),
+		escapeCSV(credential.User.DisplayName),
+	}
+}
+
+func (r *CredentialReader) recordToCredential(record []string) (Credential, error) {
+	value := func(column string) string {
+		if i, ok := r.columns[column]; ok && i < len(record) {
+			return unescapeCSV(record[i])
+		}
+		return ""
+	}
+	parseTime := func(column string) (time.Time, error) {
+		if value(column) == "" {
+			return time.Time{}, nil
+		}
+		t, err := time.Parse(time.RFC3339Nano, value(column))
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid %s: %w", column, err)
+		}
+		return t, nil
+	}
+	parseBool := func(column string) (bool, error) {
+		if value(column) == "" {
+			return false, nil
+		}
+		b, err := strconv.ParseBool(value(column))
+		if err != nil {
+			return false, fmt.Errorf("invalid %s: %w", column, err)
+		}
+		return b, nil
+	}
+
+	credential := Credential{
+		Id:   value("id"),
+		Name: value("name"),
+		User: hankoClient.User{
+			ID:          value("user_id"),
+			Name:        value("user_name"),
+			DisplayName: value("user_display_name"),
+		},
+	}
+	var err error
+	if credential.CreatedAt, err = parseTime("created_at"); err != nil {
+		return Credential{}, err
+	}
+	if credential.LastUsed, err = parseTime("last_used"); err != nil {
+		return Credential{}, err
+	}
+	if credential.UserVerification, err = parseBool("user_verification"); err != nil {
+		return Credential{}, err
+	}
+	if credential.IsResidentKey, err = parseBool("is_resident_key"); err != nil {
+		return Credential{}, err
+	}
+	authenticator := Authenticator{
+		Aaguid:     value("authenticator_aaguid"),
+		Name:       value("authenticator_name"),
+		Attachment: value("authenticator_attachment"),
+	}
+	if authenticator != (Authenticator{}) {
+		credential.Authenticator = &authenticator
+	}
+	return credential, nil
+}
@@ -0,0 +1,198 @@
+package webauthn_test
+
+import (
+	"bytes"
+	"errors"
+	hankoClient "github.com/teamhanko/hanko-go/client"
+	"github.com/teamhanko/hanko-go/hankotest"
+	"github.com/teamhanko/hanko-go/webauthn"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newExportServer() *hankotest.Server {
+	server := newPaginatingServer()
+	server.AddCredential(webauthn.Credential{
+		Id:               "security-key",
+		Name:             "Security Key, \"work\"",
+		CreatedAt:        testCreatedAt.Add(-time.Hour),
+		LastUsed:         testCreatedAt.Add(time.Hour),
+		UserVerification: true,
+		IsResidentKey:    true,
+		Authenticator:    &webauthn.Authenticator{Aaguid: "cb69481e-8ff7-4039-93ec-0a2729a154a8", Name: "YubiKey 5", Attachment: string(webauthn.CrossPlatform)},
+		User:             hankoClient.User{ID: "user", Name: "john.doe@example.com", DisplayName: "John Doe"},
+	})
+	return server
+}
+
+func TestClient_ExportCredentials(t *testing.T) {
+	for _, format := range []webauthn.ExportFormat{webauthn.FormatJSONLines, webauthn.FormatCSV} {
+		t.Run(string(format), func(t *testing.T) {
+			server := newExportServer()
+			defer server.Close()
+			client := webauthn.NewClient(server.URL, testCeremonyApiSecret).WithoutLogs()
+
+			buffer := &bytes.Buffer{}
+			count, err := client.ExportCredentials(buffer, format, webauthn.NewCredentialQuery().WithUserId("user"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected, err := client.ListAllCredentials(webauthn.NewCredentialQuery().WithUserId("user"), 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if count != 26 {
+				t.Errorf("got %d exported credentials, want 26", count)
+			}
+
+			credentials, err := webauthn.ReadCredentials(buffer, format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(credentials) != len(expected) {
+				t.Fatalf("got %d credentials, want %d", len(credentials), len(expected))
+			}
+			for i := range expected {
+				if !credentialsEqual(credentials[i], expected[i]) {
+					t.Errorf("got %+v, want %+v", credentials[i], expected[i])
+				}
+			}
+		})
+	}
+}
+
+func TestClient_ExportCredentialsEmpty(t *testing.T) {
+	server := newExportServer()
+	defer server.Close()
+	client := webauthn.NewClient(server.URL, testCeremonyApiSecret).WithoutLogs()
+
+	buffer := &bytes.Buffer{}
+	if _, err := client.ExportCredentials(buffer, webauthn.FormatCSV, webauthn.NewCredentialQuery().WithUserId("nobody")); err != nil {
+		t.Fatal(err)
+	}
+	if buffer.String() != strings.Join(webauthn.CSVHeader, ",")+"\n" {
+		t.Errorf("got %q, want only the header", buffer.String())
+	}
+	if credentials, err := webauthn.ReadCredentials(buffer, webauthn.FormatCSV); err != nil || len(credentials) != 0 {
+		t.Errorf("got %v, %v, want no credentials", credentials, err)
+	}
+
+	if _, err := client.ExportCredentials(buffer, "xml", nil); !errors.Is(err, webauthn.ErrUnknownFormat) {
+		t.Errorf("got %v, want %v", err, webauthn.ErrUnknownFormat)
+	}
+}
+
+func TestReadCredentials(t *testing.T) {
+	var tests = []struct {
+		name          string
+		format        webauthn.ExportFormat
+		input         string
+		expected      []webauthn.Credential
+		expectedError string
+	}{
+		{
+			name:     "json lines with blank lines",
+			format:   webauthn.FormatJSONLines,
+			input:    "{\"id\":\"a\",\"user\":{\"id\":\"user\"}}\n\n{\"id\":\"b\",\"user\":{\"id\":\"user\"}}",
+			expected: []webauthn.Credential{{Id: "a", User: hankoClient.User{ID: "user"}}, {Id: "b", User: hankoClient.User{ID: "user"}}},
+		},
+		{
+			name:          "malformed json",
+			format:        webauthn.FormatJSONLines,
+			input:         "{\"id\":\"a\"}\n{\"id\":",
+			expectedError: "line 2",
+		},
+		{
+			name:     "csv with reordered and missing columns",
+			format:   webauthn.FormatCSV,
+			input:    "user_id,id,is_resident_key\nuser,a,true\n",
+			expected: []webauthn.Credential{{Id: "a", IsResidentKey: true, User: hankoClient.User{ID: "user"}}},
+		},
+		{
+			name:          "csv without id column",
+			format:        webauthn.FormatCSV,
+			input:         "name\nkey\n",
+			expectedError: "missing id column",
+		},
+		{
+			name:          "csv with invalid time",
+			format:        webauthn.FormatCSV,
+			input:         "id,created_at\na,2021-01-01T12:00:00Z\nb,yesterday\n",
+			expectedError: "line 3: invalid created_at",
+		},
+		{
+			name:          "empty csv",
+			format:        webauthn.FormatCSV,
+			input:         "",
+			expectedError: "missing header",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			credentials, err := webauthn.ReadCredentials(strings.NewReader(tt.input), tt.format)
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Errorf("got %v, want an error containing %q", err, tt.expectedError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(credentials, tt.expected) {
+				t.Errorf("got %+v, want %+v", credentials, tt.expected)
+			}
+		})
+	}
+}
+
+// credentialsEqual compares credentials using time.Time.Equal, as times do not keep their location through an export.
+func credentialsEqual(a, b webauthn.Credential) bool {
+	if !a.CreatedAt.Equal(b.CreatedAt) || !a.LastUsed.Equal(b.LastUsed) {
+		return false
+	}
+	a.CreatedAt, a.LastUsed, b.CreatedAt, b.LastUsed = time.Time{}, time.Time{}, time.Time{}, time.Time{}
+	return reflect.DeepEqual(a, b)
+}
+
+func TestCredentialWriter_CSVFormulaEscaping(t *testing.T) {
+	credentials := []webauthn.Credential{
+		{Id: "formula", Name: "=HYPERLINK(\"https://attacker.example\")", User: hankoClient.User{ID: "user", DisplayName: "@SUM(A1)"}},
+		{Id: "-dash", Name: "+1", User: hankoClient.User{ID: "user", Name: "-2"}},
+		{Id: "quoted", Name: "'already quoted", User: hankoClient.User{ID: "user", Name: "plain"}},
+	}
+	buffer := &bytes.Buffer{}
+	writer, err := webauthn.NewCredentialWriter(buffer, webauthn.FormatCSV)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, credential := range credentials {
+		if err = writer.Write(credential); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = writer.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, escaped := range []string{`"'=HYPERLINK(""https://attacker.example"")"`, "'@SUM(A1)", "'-dash", "'+1", "'-2", "''already quoted"} {
+		if !strings.Contains(buffer.String(), escaped) {
+			t.Errorf("got %s, want it to contain %s", buffer.String(), escaped)
+		}
+	}
+
+	read, err := webauthn.ReadCredentials(buffer, webauthn.FormatCSV)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(read) != len(credentials) {
+		t.Fatalf("got %d credentials, want %d", len(read), len(credentials))
+	}
+	for i := range credentials {
+		if !credentialsEqual(read[i], credentials[i]) {
+			t.Errorf("got %+v, want %+v", read[i], credentials[i])
+		}
+	}
+}
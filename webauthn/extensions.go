@@ -0,0 +1,186 @@
+package webauthn
+
+import (
+	"github.com/teamhanko/webauthn/protocol"
+)
+
+// LargeBlobSupport describes the relying party's requirement for large blob storage during registration.
+//
+// See also: https://www.w3.org/TR/webauthn-2/#enumdef-largeblobsupport
+type LargeBlobSupport string
+
+const (
+	// Indicates that the registration fails if the authenticator does not support large blob storage.
+	LargeBlobRequired LargeBlobSupport = "required"
+
+	// Indicates that large blob storage is used if the authenticator supports it.
+	LargeBlobPreferred LargeBlobSupport = "preferred"
+)
+
+// CredentialProtectionPolicy is the credential protection policy requested using the credProtect extension.
+//
+// See also: https://fidoalliance.org/specs/fido-v2.1-ps-20210615/fido-client-to-authenticator-protocol-v2.1-ps-20210615.html#sctn-credProtect-extension
+type CredentialProtectionPolicy string
+
+const (
+	// Indicates that the credential can be used with and without user verification.
+	UserVerificationOptional CredentialProtectionPolicy = "userVerificationOptional"
+
+	// Indicates that the credential can only be discovered with user verification, but used without it if its ID is
+	// provided in the allowCredentials.
+	UserVerificationOptionalWithCredentialIDList CredentialProtectionPolicy = "userVerificationOptionalWithCredentialIDList"
+
+	// Indicates that the credential can only be used with user verification.
+	UserVerificationRequired CredentialProtectionPolicy = "userVerificationRequired"
+)
+
+// LargeBlobInputs are the inputs of the largeBlob extension. Support is used during registration, Read or Write
+// during authentication.
+type LargeBlobInputs struct {
+	Support LargeBlobSupport          `json:"support,omitempty"`
+	Read    bool                      `json:"read,omitempty"`
+	Write   protocol.URLEncodedBase64 `json:"write,omitempty"`
+}
+
+// PRFValues are the one or two inputs, or the outputs, of the pseudo-random function of the prf extension.
+type PRFValues struct {
+	First  protocol.URLEncodedBase64 `json:"first"`
+	Second protocol.URLEncodedBase64 `json:"second,omitempty"`
+}
+
+// PRFInputs are the inputs of the prf extension. EvalByCredential maps base64url encoded credential IDs to the inputs
+// to use for that credential and is only allowed during authentication.
+type PRFInputs struct {
+	Eval             *PRFValues           `json:"eval,omitempty"`
+	EvalByCredential map[string]PRFValues `json:"evalByCredential,omitempty"`
+}
+
+// RegistrationExtensionInputs are the client extension inputs of a registration.
+//
+// See also: https://www.w3.org/TR/webauthn-2/#sctn-extensions
+type RegistrationExtensionInputs struct {
+	CredProps                         bool                       `json:"credProps,omitempty"`
+	LargeBlob                         *LargeBlobInputs           `json:"largeBlob,omitempty"`
+	PRF                               *PRFInputs                 `json:"prf,omitempty"`
+	CredentialProtectionPolicy        CredentialProtectionPolicy `json:"credentialProtectionPolicy,omitempty"`
+	EnforceCredentialProtectionPolicy bool                       `json:"enforceCredentialProtectionPolicy,omitempty"`
+}
+
+// NewRegistrationExtensionInputs creates new RegistrationExtensionInputs without any extensions.
+func NewRegistrationExtensionInputs() *RegistrationExtensionInputs {
+	return &RegistrationExtensionInputs{}
+}
+
+// WithCredProps requests the credProps extension, which reports whether a client-side discoverable credential has
+// been created. The result is available through ClientExtensionOutputs.CredProps.
+func (inputs *RegistrationExtensionInputs) WithCredProps() *RegistrationExtensionInputs {
+	inputs.CredProps = true
+	return inputs
+}
+
+// WithLargeBlob requests the largeBlob extension with the given LargeBlobSupport.
+func (inputs *RegistrationExtensionInputs) WithLargeBlob(support LargeBlobSupport) *RegistrationExtensionInputs {
+	inputs.LargeBlob = &LargeBlobInputs{Support: support}
+	return inputs
+}
+
+// WithPRF requests the prf extension. During registration, the client reports whether the credential supports the
+// pseudo-random function. Some clients evaluate the given values already, which may be nil.
+func (inputs *RegistrationExtensionInputs) WithPRF(eval *PRFValues) *RegistrationExtensionInputs {
+	inputs.PRF = &PRFInputs{Eval: eval}
+	return inputs
+}
+
+// WithCredProtect requests the credProtect extension with the given CredentialProtectionPolicy. If enforce is true,
+// the registration fails if the authenticator does not support the policy.
+func (inputs *RegistrationExtensionInputs) WithCredProtect(policy CredentialProtectionPolicy, enforce bool) *RegistrationExtensionInputs {
+	inputs.CredentialProtectionPolicy = policy
+	inputs.EnforceCredentialProtectionPolicy = enforce
+	return inputs
+}
+
+// AuthenticationExtensionInputs are the client extension inputs of an authentication or a transaction.
+//
+// See also: https://www.w3.org/TR/webauthn-2/#sctn-extensions
+type AuthenticationExtensionInputs struct {
+	AppID     string           `json:"appid,omitempty"`
+	LargeBlob *LargeBlobInputs `json:"largeBlob,omitempty"`
+	PRF       *PRFInputs       `json:"prf,omitempty"`
+}
+
+// NewAuthenticationExtensionInputs creates new AuthenticationExtensionInputs without any extensions.
+func NewAuthenticationExtensionInputs() *AuthenticationExtensionInputs {
+	return &AuthenticationExtensionInputs{}
+}
+
+// WithAppID requests the appid extension, which allows authenticating with credentials registered using the legacy
+// FIDO U2F API for the given AppID, e.g. "https://example.com/u2f/app-id.json".
+func (inputs *AuthenticationExtensionInputs) WithAppID(appId string) *AuthenticationExtensionInputs {
+	inputs.AppID = appId
+	return inputs
+}
+
+// WithLargeBlobRead requests to read the large blob stored for the credential.
+func (inputs *AuthenticationExtensionInputs) WithLargeBlobRead() *AuthenticationExtensionInputs {
+	inputs.LargeBlob = &LargeBlobInputs{Read: true}
+	return inputs
+}
+
+// WithLargeBlobWrite requests to store the given large blob for the credential.
+func (inputs *AuthenticationExtensionInputs) WithLargeBlobWrite(blob []byte) *AuthenticationExtensionInputs {
+	inputs.LargeBlob = &LargeBlobInputs{Write: blob}
+	return inputs
+}
+
+// WithPRF requests to evaluate the pseudo-random function of the prf extension with the given values, e.g. to derive
+// an encryption key. The values given for a credential ID using WithPRFByCredential take precedence.
+func (inputs *AuthenticationExtensionInputs) WithPRF(eval PRFValues) *AuthenticationExtensionInputs {
+	if inputs.PRF == nil {
+		inputs.PRF = &PRFInputs{}
+	}
+	inputs.PRF.Eval = &eval
+	return inputs
+}
+
+// WithPRFByCredential requests to evaluate the pseudo-random function of the prf extension with the given values if
+// the credential with the given base64url encoded ID is used.
+func (inputs *AuthenticationExtensionInputs) WithPRFByCredential(credentialId string, eval PRFValues) *AuthenticationExtensionInputs {
+	if inputs.PRF == nil {
+		inputs.PRF = &PRFInputs{}
+	}
+	if inputs.PRF.EvalByCredential == nil {
+		inputs.PRF.EvalByCredential = map[string]PRFValues{}
+	}
+	inputs.PRF.EvalByCredential[credentialId] = eval
+	return inputs
+}
+
+// CredentialPropertiesOutput is the output of the credProps extension.
+type CredentialPropertiesOutput struct {
+	// Whether a client-side discoverable credential has been created. Nil if the client does not know.
+	ResidentKey *bool `json:"rk,omitempty"`
+}
+
+// LargeBlobOutputs are the outputs of the largeBlob extension.
+type LargeBlobOutputs struct {
+	Supported *bool                     `json:"supported,omitempty"` // registration: whether large blobs are supported
+	Blob      protocol.URLEncodedBase64 `json:"blob,omitempty"`      // authentication: the blob that has been read
+	Written   *bool                     `json:"written,omitempty"`   // authentication: whether the blob has been written
+}
+
+// PRFOutputs are the outputs of the prf extension.
+type PRFOutputs struct {
+	Enabled *bool      `json:"enabled,omitempty"` // registration: whether the credential supports the prf extension
+	Results *PRFValues `json:"results,omitempty"` // the results of evaluating the pseudo-random function
+}
+
+// ClientExtensionOutputs are the client extension outputs of a PublicKeyCredential, as returned by its
+// getClientExtensionResults() method. Only the outputs of requested extensions are set.
+//
+// See also: https://www.w3.org/TR/webauthn-2/#dom-publickeycredential-getclientextensionresults
+type ClientExtensionOutputs struct {
+	AppID     *bool                       `json:"appid,omitempty"`
+	CredProps *CredentialPropertiesOutput `json:"credProps,omitempty"`
+	LargeBlob *LargeBlobOutputs           `json:"largeBlob,omitempty"`
+	PRF       *PRFOutputs                 `json:"prf,omitempty"`
+}
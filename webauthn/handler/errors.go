@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/teamhanko/hanko-go/internal/httperror"
+	"net/http"
+)
+
+// Error is an error with an HTTP status code and a message to be returned to the client. Return an Error from a
+// callback to respond with the given status code and message, e.g. ErrUnauthenticated if there is no logged-in user.
+// The underlying error in Err is not returned to the client.
+type Error = httperror.Error
+
+// NewError creates a new Error with the given status code and message.
+func NewError(statusCode int, message string) *Error {
+	return httperror.NewError(statusCode, message)
+}
+
+// StatusClientClosedRequest is the non-standard status code used by MapError for requests that have been canceled.
+const StatusClientClosedRequest = httperror.StatusClientClosedRequest
+
+// ErrUnauthenticated can be returned by a UserResolver if the request has not been made by a logged-in user.
+var ErrUnauthenticated = NewError(http.StatusUnauthorized, "unauthenticated")
+
+// ErrorResponse is the JSON representation of an error written by WriteError. It has the same shape as the JSON
+// representation of a client.ApiError.
+type ErrorResponse struct {
+	Message    string `json:"message"`
+	StatusText string `json:"status_text"`
+	StatusCode int    `json:"status_code"`
+}
+
+// ErrorHandler writes the response for an error that occurred while handling a request.
+type ErrorHandler func(w http.ResponseWriter, r *http.Request, err error)
+
+// WriteError is the default ErrorHandler. It writes an ErrorResponse with the status code and message returned by
+// MapError.
+func WriteError(w http.ResponseWriter, r *http.Request, err error) {
+	statusCode, message := MapError(err)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(&ErrorResponse{
+		Message:    message,
+		StatusText: http.StatusText(statusCode),
+		StatusCode: statusCode,
+	})
+}
+
+// MapError returns the HTTP status code and the message to be returned to the client for the given error.
+//
+// An Error is mapped to its StatusCode and Message. A client.ApiError is mapped according to its category: validation
+// errors, e.g. a failed verification of a credential, result in 400, ErrNotFound in 404, ErrConflict in 409 and
+// ErrRateLimited in 429, using the message of the ApiError. ErrCanceled results in StatusClientClosedRequest, as the
+// request has usually been canceled by the client closing the connection. Errors of the Hanko API or the network as
+// well as ErrUnauthorized result in 502, since the API acts as an upstream server whose rejection of the API credentials
+// is a misconfiguration of the server rather than an error of the client. All other errors result in 500. Details of
+// these errors are not returned to the client.
+func MapError(err error) (statusCode int, message string) {
+	return httperror.MapError(err)
+}
@@ -0,0 +1,375 @@
+// Package handler provides net/http handlers for the WebAuthn ceremonies of the Hanko Authentication API.
+//
+// A Handler serves the six ceremony endpoints (registration, authentication and transaction, each consisting of an
+// initialization and a finalization) by passing the requests of the browser to a webauthn.Client and returning the
+// responses of the API. Callbacks resolve the current user, decide the options of a ceremony and act on success, e.g.
+// by creating a session:
+//
+//	h := handler.New(webauthnClient).
+//		WithUserResolver(func(r *http.Request) (*client.User, error) {
+//			user := currentUser(r)
+//			if user == nil {
+//				return nil, handler.ErrUnauthenticated
+//			}
+//			return &client.User{ID: user.ID, Name: user.Email}, nil
+//		}).
+//		OnAuthentication(func(w http.ResponseWriter, r *http.Request, response *webauthn.AuthenticationFinalizationResponse) error {
+//			return createSession(w, response.Credential.User.ID)
+//		})
+//	h.Register(mux, "/webauthn")
+//
+// Errors are written as JSON using WriteError unless configured otherwise using Handler.WithErrorHandler.
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	hankoClient "github.com/teamhanko/hanko-go/client"
+	"github.com/teamhanko/hanko-go/webauthn"
+	"net/http"
+	"strings"
+)
+
+// maxRequestBodySize limits the size of the request bodies accepted by the finalization handlers.
+const maxRequestBodySize = 1 << 20
+
+// UserResolver returns the user on whose behalf a ceremony is performed. Return ErrUnauthenticated (or any other
+// Error) to reject the request.
+type UserResolver func(r *http.Request) (*hankoClient.User, error)
+
+// RegistrationOptions can modify a RegistrationInitializationRequest before it is sent to the API, e.g. to set the
+// AuthenticatorSelection.
+type RegistrationOptions func(r *http.Request, request *webauthn.RegistrationInitializationRequest) error
+
+// AuthenticationOptions can modify an AuthenticationInitializationRequest before it is sent to the API.
+type AuthenticationOptions func(r *http.Request, request *webauthn.AuthenticationInitializationRequest) error
+
+// TransactionOptions must set the transaction text of a TransactionInitializationRequest before it is sent to the API
+// and can modify further options.
+type TransactionOptions func(r *http.Request, request *webauthn.TransactionInitializationRequest) error
+
+// RegistrationCallback is called after a successful registration, before the response is written. Returning an error
+// aborts the request.
+type RegistrationCallback func(w http.ResponseWriter, r *http.Request, response *webauthn.RegistrationFinalizationResponse) error
+
+// AuthenticationCallback is called after a successful authentication, before the response is written, e.g. to create
+// a session. Returning an error aborts the request.
+type AuthenticationCallback func(w http.ResponseWriter, r *http.Request, response *webauthn.AuthenticationFinalizationResponse) error
+
+// TransactionCallback is called after a successful transaction, before the response is written, e.g. to execute the
+// transaction. Returning an error aborts the request.
+type TransactionCallback func(w http.ResponseWriter, r *http.Request, response *webauthn.TransactionFinalizationResponse) error
+
+// Handler provides http.Handlers for the WebAuthn ceremonies. Create a Handler using New.
+type Handler struct {
+	client                *webauthn.Client
+	userResolver          UserResolver
+	loginUserResolver     UserResolver
+	registrationOptions   RegistrationOptions
+	authenticationOptions AuthenticationOptions
+	transactionOptions    TransactionOptions
+	onRegistration        RegistrationCallback
+	onAuthentication      AuthenticationCallback
+	onTransaction         TransactionCallback
+	errorHandler          ErrorHandler
+}
+
+// New creates a new Handler which uses the given webauthn.Client to communicate with the Hanko Authentication API.
+func New(client *webauthn.Client) *Handler {
+	return &Handler{client: client, errorHandler: WriteError}
+}
+
+// WithUserResolver sets the UserResolver returning the logged-in user on whose behalf credentials are registered and
+// transactions are performed. It is required for registrations and transactions.
+func (h *Handler) WithUserResolver(resolver UserResolver) *Handler {
+	h.userResolver = resolver
+	return h
+}
+
+// WithLoginUserResolver sets the UserResolver returning the user who wants to authenticate, e.g. based on a username
+// given in the request. If not set, or if it returns nil, authentication is only possible using resident credentials.
+func (h *Handler) WithLoginUserResolver(resolver UserResolver) *Handler {
+	h.loginUserResolver = resolver
+	return h
+}
+
+// WithRegistrationOptions sets the RegistrationOptions applied to every registration.
+func (h *Handler) WithRegistrationOptions(options RegistrationOptions) *Handler {
+	h.registrationOptions = options
+	return h
+}
+
+// WithAuthenticationOptions sets the AuthenticationOptions applied to every authentication.
+func (h *Handler) WithAuthenticationOptions(options AuthenticationOptions) *Handler {
+	h.authenticationOptions = options
+	return h
+}
+
+// WithTransactionOptions sets the TransactionOptions applied to every transaction. It is required for transactions,
+// since it must set the transaction text.
+func (h *Handler) WithTransactionOptions(options TransactionOptions) *Handler {
+	h.transactionOptions = options
+	return h
+}
+
+// OnRegistration sets the RegistrationCallback called after a successful registration.
+func (h *Handler) OnRegistration(callback RegistrationCallback) *Handler {
+	h.onRegistration = callback
+	return h
+}
+
+// OnAuthentication sets the AuthenticationCallback called after a successful authentication.
+func (h *Handler) OnAuthentication(callback AuthenticationCallback) *Handler {
+	h.onAuthentication = callback
+	return h
+}
+
+// OnTransaction sets the TransactionCallback called after a successful transaction.
+func (h *Handler) OnTransaction(callback TransactionCallback) *Handler {
+	h.onTransaction = callback
+	return h
+}
+
+// WithErrorHandler sets the ErrorHandler writing the response for errors. Defaults to WriteError.
+func (h *Handler) WithErrorHandler(errorHandler ErrorHandler) *Handler {
+	h.errorHandler = errorHandler
+	return h
+}
+
+// Register registers the handlers of all ceremonies with the given http.ServeMux under the given path prefix, e.g.
+// "/webauthn/registration/initialize" for the prefix "/webauthn".
+func (h *Handler) Register(mux *http.ServeMux, prefix string) {
+	prefix = strings.TrimSuffix(prefix, "/")
+	mux.Handle(prefix+"/registration/initialize", h.InitializeRegistration())
+	mux.Handle(prefix+"/registration/finalize", h.FinalizeRegistration())
+	mux.Handle(prefix+"/authentication/initialize", h.InitializeAuthentication())
+	mux.Handle(prefix+"/authentication/finalize", h.FinalizeAuthentication())
+	mux.Handle(prefix+"/transaction/initialize", h.InitializeTransaction())
+	mux.Handle(prefix+"/transaction/finalize", h.FinalizeTransaction())
+}
+
+// InitializeRegistration returns an http.Handler initializing the registration of a credential for the user returned
+// by the UserResolver. It responds with the webauthn.RegistrationInitializationResponse to be passed to
+// navigator.credentials.create().
+func (h *Handler) InitializeRegistration() http.Handler {
+	return h.post(func(w http.ResponseWriter, r *http.Request) error {
+		user, err := h.resolveUser(r, h.userResolver, true)
+		if err != nil {
+			return err
+		}
+		registrationUser := webauthn.NewRegistrationInitializationUser(user.ID, user.Name)
+		if user.DisplayName != "" {
+			registrationUser = registrationUser.WithDisplayName(user.DisplayName)
+		}
+		request := webauthn.NewRegistrationInitializationRequest(registrationUser)
+		if h.registrationOptions != nil {
+			if err = h.registrationOptions(r, request); err != nil {
+				return err
+			}
+		}
+		response, apiErr := h.client.InitializeRegistrationContext(r.Context(), request)
+		if apiErr != nil {
+			return apiErr
+		}
+		return writeJSON(w, response)
+	})
+}
+
+// FinalizeRegistration returns an http.Handler finalizing a registration using the PublicKeyCredential created by
+// navigator.credentials.create(). It calls the RegistrationCallback and responds with the
+// webauthn.RegistrationFinalizationResponse.
+//
+// If the credential has been registered for a user other than the one returned by the UserResolver, it is deleted
+// again and the request is rejected with 403.
+func (h *Handler) FinalizeRegistration() http.Handler {
+	return h.post(func(w http.ResponseWriter, r *http.Request) error {
+		user, err := h.resolveUser(r, h.userResolver, true)
+		if err != nil {
+			return err
+		}
+		request, err := webauthn.ParseRegistrationFinalizationRequest(r.Body)
+		if err != nil {
+			return &Error{StatusCode: http.StatusBadRequest, Message: "invalid request body", Err: err}
+		}
+		response, apiErr := h.client.FinalizeRegistrationContext(r.Context(), request)
+		if apiErr != nil {
+			return apiErr
+		}
+		if err = checkUser(user, response.Credential.User.ID); err != nil {
+			// the API has already registered the credential, remove it again
+			if apiErr = h.client.DeleteCredentialContext(r.Context(), response.Credential.Id); apiErr != nil {
+				return &Error{
+					StatusCode: http.StatusInternalServerError,
+					Message:    http.StatusText(http.StatusInternalServerError),
+					Err:        fmt.Errorf("%v; failed to delete the registered credential: %w", err, apiErr),
+				}
+			}
+			return err
+		}
+		if h.onRegistration != nil {
+			if err = h.onRegistration(w, r, response); err != nil {
+				return err
+			}
+		}
+		return writeJSON(w, response)
+	})
+}
+
+// InitializeAuthentication returns an http.Handler initializing an authentication of the user returned by the login
+// UserResolver. It responds with the webauthn.AuthenticationInitializationResponse to be passed to
+// navigator.credentials.get().
+func (h *Handler) InitializeAuthentication() http.Handler {
+	return h.post(func(w http.ResponseWriter, r *http.Request) error {
+		user, err := h.resolveUser(r, h.loginUserResolver, false)
+		if err != nil {
+			return err
+		}
+		request := webauthn.NewAuthenticationInitializationRequest()
+		if user != nil {
+			request.User = *user
+		}
+		if h.authenticationOptions != nil {
+			if err = h.authenticationOptions(r, request); err != nil {
+				return err
+			}
+		}
+		response, apiErr := h.client.InitializeAuthenticationContext(r.Context(), request)
+		if apiErr != nil {
+			return apiErr
+		}
+		return writeJSON(w, response)
+	})
+}
+
+// FinalizeAuthentication returns an http.Handler finalizing an authentication using the PublicKeyCredential created
+// by navigator.credentials.get(). It calls the AuthenticationCallback and responds with the
+// webauthn.AuthenticationFinalizationResponse.
+func (h *Handler) FinalizeAuthentication() http.Handler {
+	return h.post(func(w http.ResponseWriter, r *http.Request) error {
+		request, err := webauthn.ParseAuthenticationFinalizationRequest(r.Body)
+		if err != nil {
+			return &Error{StatusCode: http.StatusBadRequest, Message: "invalid request body", Err: err}
+		}
+		response, apiErr := h.client.FinalizeAuthenticationContext(r.Context(), request)
+		if apiErr != nil {
+			return apiErr
+		}
+		if h.onAuthentication != nil {
+			if err = h.onAuthentication(w, r, response); err != nil {
+				return err
+			}
+		}
+		return writeJSON(w, response)
+	})
+}
+
+// InitializeTransaction returns an http.Handler initializing a transaction of the user returned by the UserResolver.
+// The transaction text is set by the TransactionOptions. It responds with the
+// webauthn.TransactionInitializationResponse to be passed to navigator.credentials.get().
+func (h *Handler) InitializeTransaction() http.Handler {
+	return h.post(func(w http.ResponseWriter, r *http.Request) error {
+		if h.transactionOptions == nil {
+			return &Error{
+				StatusCode: http.StatusInternalServerError,
+				Message:    http.StatusText(http.StatusInternalServerError),
+				Err:        fmt.Errorf("no transaction options configured"),
+			}
+		}
+		user, err := h.resolveUser(r, h.userResolver, true)
+		if err != nil {
+			return err
+		}
+		request := webauthn.NewTransactionInitializationRequest(webauthn.NewAuthenticationInitializationUser(user.ID))
+		if err = h.transactionOptions(r, request); err != nil {
+			return err
+		}
+		response, apiErr := h.client.InitializeTransactionContext(r.Context(), request)
+		if apiErr != nil {
+			return apiErr
+		}
+		return writeJSON(w, response)
+	})
+}
+
+// FinalizeTransaction returns an http.Handler finalizing a transaction using the PublicKeyCredential created by
+// navigator.credentials.get(). It calls the TransactionCallback and responds with the
+// webauthn.TransactionFinalizationResponse.
+//
+// If the transaction has been confirmed using a credential of a user other than the one returned by the UserResolver,
+// the request is rejected with 403 without calling the TransactionCallback.
+func (h *Handler) FinalizeTransaction() http.Handler {
+	return h.post(func(w http.ResponseWriter, r *http.Request) error {
+		user, err := h.resolveUser(r, h.userResolver, true)
+		if err != nil {
+			return err
+		}
+		request, err := webauthn.ParseAuthenticationFinalizationRequest(r.Body)
+		if err != nil {
+			return &Error{StatusCode: http.StatusBadRequest, Message: "invalid request body", Err: err}
+		}
+		response, apiErr := h.client.FinalizeTransactionContext(r.Context(), &webauthn.TransactionFinalizationRequest{
+			AuthenticationFinalizationRequest: *request,
+		})
+		if apiErr != nil {
+			return apiErr
+		}
+		if err = checkUser(user, response.Credential.User.ID); err != nil {
+			return err
+		}
+		if h.onTransaction != nil {
+			if err = h.onTransaction(w, r, response); err != nil {
+				return err
+			}
+		}
+		return writeJSON(w, response)
+	})
+}
+
+// post returns an http.Handler which only accepts POST requests, limits the size of the request body and passes
+// errors returned by the given function to the ErrorHandler.
+func (h *Handler) post(handle func(w http.ResponseWriter, r *http.Request) error) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			h.errorHandler(w, r, NewError(http.StatusMethodNotAllowed, http.StatusText(http.StatusMethodNotAllowed)))
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, maxRequestBodySize)
+		if err := handle(w, r); err != nil {
+			h.errorHandler(w, r, err)
+		}
+	})
+}
+
+// resolveUser resolves the user using the given UserResolver. If required, a user with an ID must be resolved.
+func (h *Handler) resolveUser(r *http.Request, resolver UserResolver, required bool) (*hankoClient.User, error) {
+	var user *hankoClient.User
+	if resolver != nil {
+		var err error
+		if user, err = resolver(r); err != nil {
+			return nil, err
+		}
+	}
+	if required && (user == nil || user.ID == "") {
+		return nil, ErrUnauthenticated
+	}
+	return user, nil
+}
+
+// checkUser returns an Error with status 403 if a ceremony has been finalized for a user other than the given one.
+func checkUser(user *hankoClient.User, userId string) error {
+	if userId != user.ID {
+		return &Error{
+			StatusCode: http.StatusForbidden,
+			Message:    "user mismatch",
+			Err:        fmt.Errorf("ceremony finalized for user '%s' instead of '%s'", userId, user.ID),
+		}
+	}
+	return nil
+}
+
+// writeJSON writes the given value as JSON response.
+func writeJSON(w http.ResponseWriter, value interface{}) error {
+	w.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(w).Encode(value)
+}
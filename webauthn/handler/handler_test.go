@@ -0,0 +1,294 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	hankoClient "github.com/teamhanko/hanko-go/client"
+	"github.com/teamhanko/hanko-go/hankotest"
+	"github.com/teamhanko/hanko-go/webauthn"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const testApiSecret = "secret"
+
+// userFromHeader resolves the user from the X-User header, standing in for a session lookup.
+func userFromHeader(r *http.Request) (*hankoClient.User, error) {
+	id := r.Header.Get("X-User")
+	if id == "" {
+		return nil, ErrUnauthenticated
+	}
+	return &hankoClient.User{ID: id, Name: id + "@example.com"}, nil
+}
+
+func runTestHandler(h *Handler) *httptest.Server {
+	mux := http.NewServeMux()
+	h.Register(mux, "/webauthn/")
+	return httptest.NewServer(mux)
+}
+
+func post(t *testing.T, url string, user string, body interface{}, response interface{}) *http.Response {
+	t.Helper()
+	encoded, _ := json.Marshal(body)
+	request, _ := http.NewRequest(http.MethodPost, url, bytes.NewReader(encoded))
+	if user != "" {
+		request.Header.Set("X-User", user)
+	}
+	httpResponse, err := http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer httpResponse.Body.Close()
+	if response != nil {
+		_ = json.NewDecoder(httpResponse.Body).Decode(response)
+	}
+	return httpResponse
+}
+
+func TestHandler_RegistrationAndAuthentication(t *testing.T) {
+	api := hankotest.NewServer(testApiSecret)
+	defer api.Close()
+	client := webauthn.NewClient(api.URL, testApiSecret).WithoutLogs()
+	authenticator := hankotest.NewAuthenticator()
+
+	var registered, authenticated string
+	h := New(client).
+		WithUserResolver(userFromHeader).
+		WithRegistrationOptions(func(r *http.Request, request *webauthn.RegistrationInitializationRequest) error {
+			request.WithAuthenticatorSelection(webauthn.NewAuthenticatorSelection().WithRequireResidentKey(true))
+			return nil
+		}).
+		OnRegistration(func(w http.ResponseWriter, r *http.Request, response *webauthn.RegistrationFinalizationResponse) error {
+			registered = response.Credential.User.ID
+			return nil
+		}).
+		OnAuthentication(func(w http.ResponseWriter, r *http.Request, response *webauthn.AuthenticationFinalizationResponse) error {
+			authenticated = response.Credential.User.ID
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: authenticated})
+			return nil
+		})
+	ts := runTestHandler(h)
+	defer ts.Close()
+
+	registrationInitialization := &webauthn.RegistrationInitializationResponse{}
+	response := post(t, ts.URL+"/webauthn/registration/initialize", "user", nil, registrationInitialization)
+	if response.StatusCode != http.StatusOK {
+		t.Fatalf("got status %d, want 200", response.StatusCode)
+	}
+	if registrationInitialization.Response.AuthenticatorSelection.RequireResidentKey == nil ||
+		!*registrationInitialization.Response.AuthenticatorSelection.RequireResidentKey {
+		t.Error("expected the registration options to be applied")
+	}
+	registrationFinalization, err := authenticator.Register(registrationInitialization)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response = post(t, ts.URL+"/webauthn/registration/finalize", "user", registrationFinalization, nil)
+	if response.StatusCode != http.StatusOK || registered != "user" {
+		t.Fatalf("got status %d and registered user %q", response.StatusCode, registered)
+	}
+
+	authenticationInitialization := &webauthn.AuthenticationInitializationResponse{}
+	post(t, ts.URL+"/webauthn/authentication/initialize", "", nil, authenticationInitialization)
+	authenticationFinalization, err := authenticator.Authenticate(authenticationInitialization)
+	if err != nil {
+		t.Fatal(err)
+	}
+	authenticationResponse := &webauthn.AuthenticationFinalizationResponse{}
+	response = post(t, ts.URL+"/webauthn/authentication/finalize", "", authenticationFinalization, authenticationResponse)
+	if response.StatusCode != http.StatusOK || authenticated != "user" || authenticationResponse.Credential.User.ID != "user" {
+		t.Fatalf("got status %d and authenticated user %q", response.StatusCode, authenticated)
+	}
+	if len(response.Cookies()) != 1 || response.Cookies()[0].Value != "user" {
+		t.Errorf("got cookies %v, want the session cookie set by the callback", response.Cookies())
+	}
+
+	// a replayed assertion must be rejected
+	errorResponse := &ErrorResponse{}
+	response = post(t, ts.URL+"/webauthn/authentication/finalize", "", authenticationFinalization, errorResponse)
+	if response.StatusCode != http.StatusBadRequest || errorResponse.StatusCode != http.StatusBadRequest {
+		t.Errorf("got status %d and %+v, want 400", response.StatusCode, errorResponse)
+	}
+}
+
+func TestHandler_Transaction(t *testing.T) {
+	api := hankotest.NewServer(testApiSecret)
+	defer api.Close()
+	client := webauthn.NewClient(api.URL, testApiSecret).WithoutLogs()
+	authenticator := hankotest.NewAuthenticator()
+	initialization, _ := client.InitializeRegistration(webauthn.NewRegistrationInitializationRequest(
+		webauthn.NewRegistrationInitializationUser("user", "user@example.com")))
+	finalization, _ := authenticator.Register(initialization)
+	if _, apiErr := client.FinalizeRegistration(finalization); apiErr != nil {
+		t.Fatal(apiErr)
+	}
+
+	h := New(client).WithUserResolver(userFromHeader)
+	ts := runTestHandler(h)
+	defer ts.Close()
+
+	response := post(t, ts.URL+"/webauthn/transaction/initialize", "user", nil, nil)
+	if response.StatusCode != http.StatusInternalServerError {
+		t.Errorf("got status %d, want 500 without transaction options", response.StatusCode)
+	}
+
+	executed := false
+	h.WithTransactionOptions(func(r *http.Request, request *webauthn.TransactionInitializationRequest) error {
+		request.WithTransaction("transfer 100 EUR")
+		return nil
+	}).OnTransaction(func(w http.ResponseWriter, r *http.Request, response *webauthn.TransactionFinalizationResponse) error {
+		executed = true
+		return nil
+	})
+	transactionInitialization := &webauthn.TransactionInitializationResponse{}
+	post(t, ts.URL+"/webauthn/transaction/initialize", "user", nil, transactionInitialization)
+	transactionFinalization, err := authenticator.AuthenticateTransaction(transactionInitialization)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response = post(t, ts.URL+"/webauthn/transaction/finalize", "user", transactionFinalization, nil)
+	if response.StatusCode != http.StatusOK || !executed {
+		t.Errorf("got status %d, executed %t", response.StatusCode, executed)
+	}
+}
+
+func TestHandler_UserMismatch(t *testing.T) {
+	api := hankotest.NewServer(testApiSecret)
+	defer api.Close()
+	client := webauthn.NewClient(api.URL, testApiSecret).WithoutLogs()
+	authenticator := hankotest.NewAuthenticator()
+
+	called := false
+	h := New(client).
+		WithUserResolver(userFromHeader).
+		WithTransactionOptions(func(r *http.Request, request *webauthn.TransactionInitializationRequest) error {
+			request.WithTransaction("transfer 100 EUR")
+			return nil
+		}).
+		OnRegistration(func(w http.ResponseWriter, r *http.Request, response *webauthn.RegistrationFinalizationResponse) error {
+			called = true
+			return nil
+		}).
+		OnTransaction(func(w http.ResponseWriter, r *http.Request, response *webauthn.TransactionFinalizationResponse) error {
+			called = true
+			return nil
+		})
+	ts := runTestHandler(h)
+	defer ts.Close()
+
+	// a registration initialized by "user" must not be finalized by "other"
+	registrationInitialization := &webauthn.RegistrationInitializationResponse{}
+	post(t, ts.URL+"/webauthn/registration/initialize", "user", nil, registrationInitialization)
+	registrationFinalization, err := authenticator.Register(registrationInitialization)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response := post(t, ts.URL+"/webauthn/registration/finalize", "", registrationFinalization, nil)
+	if response.StatusCode != http.StatusUnauthorized {
+		t.Errorf("got status %d, want 401 without a logged-in user", response.StatusCode)
+	}
+	response = post(t, ts.URL+"/webauthn/registration/finalize", "other", registrationFinalization, nil)
+	if response.StatusCode != http.StatusForbidden || called {
+		t.Fatalf("got status %d, callback called %t, want 403", response.StatusCode, called)
+	}
+	credentials, apiErr := client.ListCredentials(webauthn.NewCredentialQuery().WithUserId("user"))
+	if apiErr != nil {
+		t.Fatal(apiErr)
+	}
+	if len(*credentials) != 0 {
+		t.Errorf("expected the credential registered for the wrong user to be deleted, got %+v", *credentials)
+	}
+
+	// a transaction of "user" must not be finalized by "other"
+	initialization, _ := client.InitializeRegistration(webauthn.NewRegistrationInitializationRequest(
+		webauthn.NewRegistrationInitializationUser("user", "user@example.com")))
+	finalization, _ := authenticator.Register(initialization)
+	if _, apiErr = client.FinalizeRegistration(finalization); apiErr != nil {
+		t.Fatal(apiErr)
+	}
+	transactionInitialization := &webauthn.TransactionInitializationResponse{}
+	post(t, ts.URL+"/webauthn/transaction/initialize", "user", nil, transactionInitialization)
+	transactionFinalization, err := authenticator.AuthenticateTransaction(transactionInitialization)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response = post(t, ts.URL+"/webauthn/transaction/finalize", "other", transactionFinalization, nil)
+	if response.StatusCode != http.StatusForbidden || called {
+		t.Errorf("got status %d, callback called %t, want 403", response.StatusCode, called)
+	}
+}
+
+func TestHandler_Errors(t *testing.T) {
+	api := hankotest.NewServer(testApiSecret)
+	defer api.Close()
+	client := webauthn.NewClient(api.URL, testApiSecret).WithoutLogs()
+	h := New(client).
+		WithUserResolver(userFromHeader).
+		WithAuthenticationOptions(func(r *http.Request, request *webauthn.AuthenticationInitializationRequest) error {
+			return NewError(http.StatusTeapot, "no coffee")
+		})
+	ts := runTestHandler(h)
+	defer ts.Close()
+
+	var tests = []struct {
+		name           string
+		method         string
+		path           string
+		user           string
+		body           string
+		expectedStatus int
+	}{
+		{name: "method not allowed", method: http.MethodGet, path: "/webauthn/registration/initialize", user: "user", expectedStatus: http.StatusMethodNotAllowed},
+		{name: "unauthenticated", method: http.MethodPost, path: "/webauthn/registration/initialize", expectedStatus: http.StatusUnauthorized},
+		{name: "invalid body", method: http.MethodPost, path: "/webauthn/registration/finalize", user: "user", body: "{", expectedStatus: http.StatusBadRequest},
+		{name: "api validation error", method: http.MethodPost, path: "/webauthn/registration/finalize", user: "user", body: "{}", expectedStatus: http.StatusBadRequest},
+		{name: "callback error", method: http.MethodPost, path: "/webauthn/authentication/initialize", expectedStatus: http.StatusTeapot},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request, _ := http.NewRequest(tt.method, ts.URL+tt.path, bytes.NewBufferString(tt.body))
+			request.Header.Set("X-User", tt.user)
+			response, err := http.DefaultClient.Do(request)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer response.Body.Close()
+			errorResponse := &ErrorResponse{}
+			if err = json.NewDecoder(response.Body).Decode(errorResponse); err != nil {
+				t.Fatal(err)
+			}
+			if response.StatusCode != tt.expectedStatus || errorResponse.StatusCode != tt.expectedStatus {
+				t.Errorf("got status %d and %+v, want %d", response.StatusCode, errorResponse, tt.expectedStatus)
+			}
+		})
+	}
+}
+
+func TestMapError(t *testing.T) {
+	var tests = []struct {
+		name            string
+		err             error
+		expectedStatus  int
+		expectedMessage string
+	}{
+		{name: "handler error", err: NewError(http.StatusForbidden, "forbidden"), expectedStatus: http.StatusForbidden, expectedMessage: "forbidden"},
+		{name: "validation", err: &hankoClient.ApiError{Message: "invalid", StatusCode: 422}, expectedStatus: http.StatusBadRequest, expectedMessage: "invalid"},
+		{name: "not found", err: &hankoClient.ApiError{Message: "unknown", StatusCode: 404}, expectedStatus: http.StatusNotFound, expectedMessage: "unknown"},
+		{name: "rate limited", err: &hankoClient.ApiError{Message: "slow down", StatusCode: 429}, expectedStatus: http.StatusTooManyRequests, expectedMessage: "slow down"},
+		{name: "server error", err: &hankoClient.ApiError{Message: "secret details", StatusCode: 503}, expectedStatus: http.StatusBadGateway, expectedMessage: "Bad Gateway"},
+		{name: "rejected api credentials", err: &hankoClient.ApiError{Message: "unauthorized", StatusCode: 401}, expectedStatus: http.StatusBadGateway, expectedMessage: "Bad Gateway"},
+		{name: "canceled", err: hankoClient.WrapError(context.Canceled), expectedStatus: StatusClientClosedRequest, expectedMessage: "request canceled"},
+		{name: "other error", err: errors.New("boom"), expectedStatus: http.StatusInternalServerError, expectedMessage: "Internal Server Error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, message := MapError(tt.err)
+			if status != tt.expectedStatus || message != tt.expectedMessage {
+				t.Errorf("got %d %q, want %d %q", status, message, tt.expectedStatus, tt.expectedMessage)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package webauthn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+const (
+	// DefaultPageSize is the page size used by the Hanko Authentication API if a CredentialQuery does not specify one.
+	DefaultPageSize = 10
+
+	// DefaultListLimit is the maximum number of credentials returned by ListAllCredentials if no limit is given.
+	DefaultListLimit = 10000
+)
+
+// ErrListLimitExceeded is returned by ListAllCredentials if there are more credentials than the given limit.
+var ErrListLimitExceeded = errors.New("credential list limit exceeded")
+
+// CredentialIterator walks all pages of the credentials matching a CredentialQuery, requesting the next page from the
+// API when the current one has been consumed. Create a CredentialIterator using Client.IterateCredentials:
+//
+//	it := client.IterateCredentials(webauthn.NewCredentialQuery().WithUserId(userId))
+//	for it.Next() {
+//		credential := it.Credential()
+//		...
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+//
+// The last page has been reached when the API returns fewer credentials than the page size. Client-side filters of
+// the CredentialQuery are applied, but credentials are returned in the order of the API. A CredentialIterator is not
+// safe for concurrent use.
+type CredentialIterator struct {
+	client  *Client
+	ctx     context.Context
+	query   CredentialQuery
+	page    []Credential
+	index   int
+	current Credential
+	last    bool
+	err     error
+}
+
+// IterateCredentials returns a CredentialIterator over all credentials matching the given CredentialQuery, starting
+// at its Page (or the first page, if not set). The query is not modified.
+func (c *Client) IterateCredentials(credentialQuery *CredentialQuery) *CredentialIterator {
+	return c.IterateCredentialsContext(context.Background(), credentialQuery)
+}
+
+// IterateCredentialsContext is like IterateCredentials but uses the given context.Context for the requests to the
+// API. Iteration stops when the context is canceled or its deadline is exceeded.
+func (c *Client) IterateCredentialsContext(ctx context.Context, credentialQuery *CredentialQuery) *CredentialIterator {
+	query := CredentialQuery{}
+	if credentialQuery != nil {
+		query = *credentialQuery
+	}
+	if query.PageSize == 0 {
+		query.PageSize = DefaultPageSize
+	}
+	if query.Page == 0 {
+		query.Page = 1
+	}
+	return &CredentialIterator{client: c, ctx: ctx, query: query}
+}
+
+// Next advances the CredentialIterator to the next Credential, which is then available through Credential. It
+// returns false when there are no more credentials or an error occurred, which is then available through Err.
+func (it *CredentialIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	if err := it.ctx.Err(); err != nil {
+		it.err = err
+		return false
+	}
+	for it.index >= len(it.page) {
+		if it.last {
+			return false
+		}
+		page, apiErr := it.client.listCredentials(it.ctx, &it.query)
+		if apiErr != nil {
+			it.err = apiErr
+			return false
+		}
+		it.last = uint(len(*page)) < it.query.PageSize
+		it.page = it.query.filter(*page)
+		it.index = 0
+		it.query.Page++
+	}
+	it.current = it.page[it.index]
+	it.index++
+	return true
+}
+
+// Credential returns the current Credential.
+func (it *CredentialIterator) Credential() Credential {
+	return it.current
+}
+
+// Err returns the error that stopped the iteration, if any. Errors of the API are returned as *client.ApiError.
+func (it *CredentialIterator) Err() error {
+	return it.err
+}
+
+// ListAllCredentials returns all credentials matching the given CredentialQuery by walking all pages, sorted according
+// to the CredentialQuery. To protect against unbounded memory use, it returns ErrListLimitExceeded if there are more
+// than limit credentials. A limit of zero means DefaultListLimit.
+func (c *Client) ListAllCredentials(credentialQuery *CredentialQuery, limit int) ([]Credential, error) {
+	return c.ListAllCredentialsContext(context.Background(), credentialQuery, limit)
+}
+
+// ListAllCredentialsContext is like ListAllCredentials but uses the given context.Context for the requests to the
+// API.
+func (c *Client) ListAllCredentialsContext(ctx context.Context, credentialQuery *CredentialQuery, limit int) ([]Credential, error) {
+	if limit <= 0 {
+		limit = DefaultListLimit
+	}
+	credentials := []Credential{}
+	it := c.IterateCredentialsContext(ctx, credentialQuery)
+	for it.Next() {
+		if len(credentials) == limit {
+			return nil, fmt.Errorf("%w: more than %d credentials", ErrListLimitExceeded, limit)
+		}
+		credentials = append(credentials, it.Credential())
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+	it.query.sort(credentials)
+	return credentials, nil
+}
@@ -0,0 +1,132 @@
+package webauthn_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	hankoClient "github.com/teamhanko/hanko-go/client"
+	"github.com/teamhanko/hanko-go/hankotest"
+	"github.com/teamhanko/hanko-go/webauthn"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// testCreatedAt is the creation time of the first credential of the paginating server.
+var testCreatedAt = time.Date(2021, 1, 1, 12, 0, 0, 0, time.UTC)
+
+// newPaginatingServer returns a fake API with 25 credentials of "user" and 3 of "other", created a second apart.
+func newPaginatingServer() *hankotest.Server {
+	server := hankotest.NewServer(testCeremonyApiSecret)
+	createdAt := testCreatedAt
+	for i := 0; i < 28; i++ {
+		userId := "user"
+		if i >= 25 {
+			userId = "other"
+		}
+		server.AddCredential(webauthn.Credential{
+			Id:        fmt.Sprintf("credential-%02d", i),
+			CreatedAt: createdAt.Add(time.Duration(i) * time.Second),
+			User:      hankoClient.User{ID: userId},
+		})
+	}
+	return server
+}
+
+func TestClient_IterateCredentials(t *testing.T) {
+	server := newPaginatingServer()
+	defer server.Close()
+	client := webauthn.NewClient(server.URL, testCeremonyApiSecret).WithoutLogs()
+
+	var tests = []struct {
+		name          string
+		query         *webauthn.CredentialQuery
+		expectedCount int
+		expectedPages int
+	}{
+		{name: "whole relying party", query: nil, expectedCount: 28, expectedPages: 3},
+		{name: "user", query: webauthn.NewCredentialQuery().WithUserId("user"), expectedCount: 25, expectedPages: 3},
+		{name: "page size", query: webauthn.NewCredentialQuery().WithUserId("user").WithPageSize(5), expectedCount: 25, expectedPages: 6},
+		{name: "start page", query: webauthn.NewCredentialQuery().WithUserId("user").WithPage(2), expectedCount: 15, expectedPages: 2},
+		{name: "no credentials", query: webauthn.NewCredentialQuery().WithUserId("nobody"), expectedCount: 0, expectedPages: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server.ResetRequests()
+			seen := map[string]bool{}
+			it := client.IterateCredentials(tt.query)
+			for it.Next() {
+				seen[it.Credential().Id] = true
+			}
+			if it.Err() != nil {
+				t.Fatal(it.Err())
+			}
+			if len(seen) != tt.expectedCount || len(server.Requests()) != tt.expectedPages {
+				t.Errorf("got %d credentials in %d requests, want %d in %d", len(seen), len(server.Requests()), tt.expectedCount, tt.expectedPages)
+			}
+		})
+	}
+}
+
+func TestClient_ListAllCredentials(t *testing.T) {
+	server := newPaginatingServer()
+	defer server.Close()
+	client := webauthn.NewClient(server.URL, testCeremonyApiSecret).WithoutLogs()
+
+	credentials, err := client.ListAllCredentials(webauthn.NewCredentialQuery().WithUserId("user"), 25)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(credentials) != 25 || credentials[0].Id != "credential-00" || credentials[24].Id != "credential-24" {
+		t.Errorf("got %d credentials, want all 25 in order", len(credentials))
+	}
+
+	if _, err = client.ListAllCredentials(webauthn.NewCredentialQuery().WithUserId("user"), 24); !errors.Is(err, webauthn.ErrListLimitExceeded) {
+		t.Errorf("got %v, want %v", err, webauthn.ErrListLimitExceeded)
+	}
+
+	server.FailNext(http.MethodGet, "/v1/webauthn/credentials", &hankoClient.ApiError{StatusCode: http.StatusServiceUnavailable})
+	if _, err = client.ListAllCredentials(nil, 0); !hankoClient.IsServerError(err) {
+		t.Errorf("got %v, want a server error", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err = client.ListAllCredentialsContext(ctx, nil, 0); !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestClient_ListAllCredentialsFiltered(t *testing.T) {
+	server := newPaginatingServer()
+	defer server.Close()
+	client := webauthn.NewClient(server.URL, testCeremonyApiSecret).WithoutLogs()
+
+	// filters are applied across pages, sorting to the whole result
+	credentialQuery := webauthn.NewCredentialQuery().WithUserId("user").
+		WithCreatedBetween(testCreatedAt.Add(5*time.Second), testCreatedAt.Add(15*time.Second)).
+		WithSort(webauthn.SortByCreatedAt, true)
+	credentials, err := client.ListAllCredentials(credentialQuery, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(credentials) != 10 || credentials[0].Id != "credential-14" || credentials[9].Id != "credential-05" {
+		t.Errorf("got %d credentials, want credential-14 to credential-05", len(credentials))
+	}
+
+	// filtering or sorting a single page is refused, as it would return short or unordered pages
+	server.ResetRequests()
+	for _, credentialQuery := range []*webauthn.CredentialQuery{
+		webauthn.NewCredentialQuery().WithUserId("user").WithPage(2).
+			WithCreatedBetween(time.Time{}, testCreatedAt.Add(15*time.Second)),
+		webauthn.NewCredentialQuery().WithUserId("user").WithSort(webauthn.SortByName, false),
+	} {
+		_, apiErr := client.ListCredentials(credentialQuery)
+		if !hankoClient.IsValidationError(apiErr) {
+			t.Errorf("got %v, want a validation error", apiErr)
+		}
+	}
+	if len(server.Requests()) != 0 {
+		t.Errorf("expected no requests to the API, got %d", len(server.Requests()))
+	}
+}
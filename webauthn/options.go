@@ -2,7 +2,7 @@ package webauthn
 
 import (
 	log "github.com/sirupsen/logrus"
-	"io/ioutil"
+	hankoClient "github.com/teamhanko/hanko-go/client"
 	"net/http"
 )
 
@@ -26,26 +26,66 @@ func (c *Client) WithHttpClient(httpClient *http.Client) *Client {
 	return c
 }
 
+// WithRetryPolicy allows you to set a client.RetryPolicy that decides whether failed requests are attempted again,
+// e.g. a client.BackoffRetryPolicy. By default, requests are not retried.
+func (c *Client) WithRetryPolicy(retryPolicy hankoClient.RetryPolicy) *Client {
+	c.client.SetRetryPolicy(retryPolicy)
+	return c
+}
+
+// WithMiddleware allows you to add client.Middleware that wraps every call to the Hanko Authentication API, e.g. to
+// add headers, record timings or reject requests. Middleware added first is called first.
+func (c *Client) WithMiddleware(middleware ...hankoClient.Middleware) *Client {
+	c.client.AddMiddleware(middleware...)
+	return c
+}
+
 // WithLogger allows you to set your own custom logrus.Logger.
 func (c *Client) WithLogger(logger *log.Logger) *Client {
 	c.client.SetLogger(logger)
 	return c
 }
 
-// WithoutLogs allows you to disable logging by setting an io.Writer that discards the logs.
+// WithLogAdapter allows you to set a custom client.Logger in case you do not use logrus, e.g. a slog.Logger wrapped
+// using client.NewSlogLogger.
+func (c *Client) WithLogAdapter(logger hankoClient.Logger) *Client {
+	c.client.SetLogAdapter(logger)
+	return c
+}
+
+// WithRedactor allows you to set the client.Redactor used to redact secrets and personal data (e.g. email addresses
+// or WebAuthn attestations) from request and response bodies before they are logged. By default, a client.Redactor
+// created by client.NewRedactor is used.
+func (c *Client) WithRedactor(redactor *hankoClient.Redactor) *Client {
+	c.client.SetRedactor(redactor)
+	return c
+}
+
+// WithoutLogs allows you to disable logging by setting an io.Writer that discards the logs of the logrus.Logger, which
+// WithLogLevel and WithLogFormatter still configure. A custom client.Logger set using WithLogAdapter is replaced by
+// client.NewNopLogger. Use WithLogger or WithLogAdapter to enable logging again.
 func (c *Client) WithoutLogs() *Client {
-	c.client.SetLogWriter(ioutil.Discard)
+	c.client.DiscardLogs()
 	return c
 }
 
-// WithLogLevel allows you to set the specified logrus.Level. The default level is logrus.InfoLevel.
+// WithLogLevel allows you to set the specified logrus.Level. The default level is logrus.InfoLevel. It only takes effect
+// if the Client writes to a logrus.Logger.
 func (c *Client) WithLogLevel(level log.Level) *Client {
 	c.client.SetLogLevel(level)
 	return c
 }
 
-// WithLogFormatter allows you to set the specified logrus.Formatter. The default formatter is logrus.JSONFormatter.
+// WithLogFormatter allows you to set the specified logrus.Formatter. The default formatter is logrus.JSONFormatter. It
+// only takes effect if the Client writes to a logrus.Logger.
 func (c *Client) WithLogFormatter(formatter log.Formatter) *Client {
 	c.client.SetLogFormatter(formatter)
 	return c
 }
+
+// WithBulkConcurrency allows you to set the maximum number of concurrent requests made by bulk operations like
+// DeleteCredentials. The default is DefaultBulkConcurrency.
+func (c *Client) WithBulkConcurrency(concurrency int) *Client {
+	c.bulkConcurrency = concurrency
+	return c
+}
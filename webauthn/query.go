@@ -0,0 +1,83 @@
+package webauthn
+
+import (
+	"sort"
+	"strings"
+)
+
+// Matches reports whether the given Credential matches the client-side filters of the CredentialQuery, i.e. all
+// filters except UserId, which is applied by the API.
+func (c *CredentialQuery) Matches(credential Credential) bool {
+	if c.NameContains != "" && !strings.Contains(strings.ToLower(credential.Name), strings.ToLower(c.NameContains)) {
+		return false
+	}
+	if c.Aaguid != "" && (credential.Authenticator == nil || !strings.EqualFold(credential.Authenticator.Aaguid, c.Aaguid)) {
+		return false
+	}
+	if c.AuthenticatorAttachment != "" &&
+		(credential.Authenticator == nil || credential.Authenticator.Attachment != string(c.AuthenticatorAttachment)) {
+		return false
+	}
+	if c.IsResidentKey != nil && credential.IsResidentKey != *c.IsResidentKey {
+		return false
+	}
+	if c.UserVerification != nil && credential.UserVerification != *c.UserVerification {
+		return false
+	}
+	if !c.CreatedAfter.IsZero() && credential.CreatedAt.Before(c.CreatedAfter) {
+		return false
+	}
+	if !c.CreatedBefore.IsZero() && !credential.CreatedAt.Before(c.CreatedBefore) {
+		return false
+	}
+	if !c.LastUsedAfter.IsZero() && credential.LastUsed.Before(c.LastUsedAfter) {
+		return false
+	}
+	if !c.LastUsedBefore.IsZero() && !credential.LastUsed.Before(c.LastUsedBefore) {
+		return false
+	}
+	return true
+}
+
+// hasFilters reports whether the CredentialQuery has client-side filters.
+func (c *CredentialQuery) hasFilters() bool {
+	return c.NameContains != "" || c.Aaguid != "" || c.AuthenticatorAttachment != "" || c.IsResidentKey != nil ||
+		c.UserVerification != nil || !c.CreatedAfter.IsZero() || !c.CreatedBefore.IsZero() ||
+		!c.LastUsedAfter.IsZero() || !c.LastUsedBefore.IsZero()
+}
+
+// filter returns the credentials matching the client-side filters of the CredentialQuery.
+func (c *CredentialQuery) filter(credentials []Credential) []Credential {
+	if !c.hasFilters() {
+		return credentials
+	}
+	filtered := []Credential{}
+	for _, credential := range credentials {
+		if c.Matches(credential) {
+			filtered = append(filtered, credential)
+		}
+	}
+	return filtered
+}
+
+// sort sorts the credentials according to the sort order of the CredentialQuery, keeping the order of the API for
+// equal values.
+func (c *CredentialQuery) sort(credentials []Credential) {
+	var less func(a, b *Credential) bool
+	switch c.SortBy {
+	case SortByCreatedAt:
+		less = func(a, b *Credential) bool { return a.CreatedAt.Before(b.CreatedAt) }
+	case SortByLastUsed:
+		less = func(a, b *Credential) bool { return a.LastUsed.Before(b.LastUsed) }
+	case SortByName:
+		less = func(a, b *Credential) bool { return a.Name < b.Name }
+	default:
+		return
+	}
+	sort.SliceStable(credentials, func(i, j int) bool {
+		if c.SortDescending {
+			return less(&credentials[j], &credentials[i])
+		}
+		return less(&credentials[i], &credentials[j])
+	})
+}
@@ -0,0 +1,99 @@
+package webauthn
+
+import (
+	"github.com/google/go-querystring/query"
+	"testing"
+	"time"
+)
+
+func TestCredentialQuery_Matches(t *testing.T) {
+	now := time.Now()
+	credential := Credential{
+		Name:             "My Security Key",
+		CreatedAt:        now.Add(-48 * time.Hour),
+		LastUsed:         now.Add(-time.Hour),
+		UserVerification: true,
+		IsResidentKey:    false,
+		Authenticator:    &Authenticator{Aaguid: "CB69481E-8FF7-4039-93EC-0A2729A154A8", Attachment: string(CrossPlatform)},
+	}
+
+	var tests = []struct {
+		name     string
+		query    *CredentialQuery
+		expected bool
+	}{
+		{name: "no filters", query: NewCredentialQuery(), expected: true},
+		{name: "name", query: NewCredentialQuery().WithNameContains("security"), expected: true},
+		{name: "other name", query: NewCredentialQuery().WithNameContains("phone"), expected: false},
+		{name: "aaguid", query: NewCredentialQuery().WithAaguid("cb69481e-8ff7-4039-93ec-0a2729a154a8"), expected: true},
+		{name: "other aaguid", query: NewCredentialQuery().WithAaguid("00000000-0000-0000-0000-000000000000"), expected: false},
+		{name: "attachment", query: NewCredentialQuery().WithAuthenticatorAttachment(CrossPlatform), expected: true},
+		{name: "other attachment", query: NewCredentialQuery().WithAuthenticatorAttachment(Platform), expected: false},
+		{name: "resident key", query: NewCredentialQuery().WithResidentKey(true), expected: false},
+		{name: "non-resident key", query: NewCredentialQuery().WithResidentKey(false), expected: true},
+		{name: "user verification", query: NewCredentialQuery().WithUserVerification(true), expected: true},
+		{name: "created between", query: NewCredentialQuery().WithCreatedBetween(now.Add(-72*time.Hour), now.Add(-24*time.Hour)), expected: true},
+		{name: "created after", query: NewCredentialQuery().WithCreatedBetween(now.Add(-24*time.Hour), time.Time{}), expected: false},
+		{name: "created before bound is exclusive", query: NewCredentialQuery().WithCreatedBetween(time.Time{}, credential.CreatedAt), expected: false},
+		{name: "last used after", query: NewCredentialQuery().WithLastUsedBetween(now.Add(-2*time.Hour), time.Time{}), expected: true},
+		{name: "last used before", query: NewCredentialQuery().WithLastUsedBetween(time.Time{}, now.Add(-2*time.Hour)), expected: false},
+		{name: "all filters", query: NewCredentialQuery().WithNameContains("key").WithAuthenticatorAttachment(CrossPlatform).WithResidentKey(false), expected: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if matches := tt.query.Matches(credential); matches != tt.expected {
+				t.Errorf("got %t, want %t", matches, tt.expected)
+			}
+		})
+	}
+
+	if NewCredentialQuery().WithAaguid("aaguid").Matches(Credential{}) {
+		t.Error("expected a credential without authenticator not to match an aaguid")
+	}
+}
+
+func TestCredentialQuery_Sort(t *testing.T) {
+	now := time.Now()
+	credentials := func() []Credential {
+		return []Credential{
+			{Id: "b", Name: "b", CreatedAt: now.Add(-2 * time.Hour), LastUsed: now.Add(-time.Hour)},
+			{Id: "never", Name: "c", CreatedAt: now.Add(-time.Hour)},
+			{Id: "a", Name: "a", CreatedAt: now.Add(-3 * time.Hour), LastUsed: now},
+		}
+	}
+
+	var tests = []struct {
+		name        string
+		query       *CredentialQuery
+		expectedIds []string
+	}{
+		{name: "unsorted", query: NewCredentialQuery(), expectedIds: []string{"b", "never", "a"}},
+		{name: "last used", query: NewCredentialQuery().WithSort(SortByLastUsed, false), expectedIds: []string{"never", "b", "a"}},
+		{name: "last used descending", query: NewCredentialQuery().WithSort(SortByLastUsed, true), expectedIds: []string{"a", "b", "never"}},
+		{name: "created at", query: NewCredentialQuery().WithSort(SortByCreatedAt, false), expectedIds: []string{"a", "b", "never"}},
+		{name: "name descending", query: NewCredentialQuery().WithSort(SortByName, true), expectedIds: []string{"never", "b", "a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sorted := credentials()
+			tt.query.sort(sorted)
+			for i, id := range tt.expectedIds {
+				if sorted[i].Id != id {
+					t.Errorf("got %s at %d, want %s", sorted[i].Id, i, id)
+				}
+			}
+		})
+	}
+}
+
+func TestCredentialQuery_ServerSideParameters(t *testing.T) {
+	credentialQuery := NewCredentialQuery().WithUserId("user").WithPage(2).WithPageSize(5).
+		WithNameContains("key").WithResidentKey(true).WithSort(SortByLastUsed, true)
+	values, err := query.Values(credentialQuery)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(values) != 3 || values.Get("user_id") != "user" || values.Get("page") != "2" || values.Get("page_size") != "5" {
+		t.Errorf("got %v, want only user_id, page and page_size to be sent to the API", values)
+	}
+}
@@ -0,0 +1,239 @@
+// Package webauthn provides a variant of the webauthn.Client of package github.com/teamhanko/hanko-go/webauthn whose
+// methods return the standard error interface instead of a *client.ApiError.
+//
+// Returning a concrete *client.ApiError means that assigning the result to a variable of type error yields a non-nil
+// error even on success:
+//
+//	var err error
+//	_, err = v1Client.InitializeRegistration(request) // err != nil, even if the call succeeded
+//
+// The methods of this package return a nil error on success. On failure, the returned error is a *client.ApiError
+// which can be extracted using errors.As or checked using errors.Is and the predicates of the client package.
+//
+// Migration: Configure a webauthn.Client as before and wrap it using NewClient. The request and response types of
+// package github.com/teamhanko/hanko-go/webauthn are used unchanged. Replace checks like
+//
+//	if apiErr != nil && apiErr.StatusCode == 404 { ... }
+//
+// with
+//
+//	var apiErr *client.ApiError
+//	if errors.As(err, &apiErr) && apiErr.StatusCode == 404 { ... }
+//
+// or simply client.IsNotFound(err). Callers who want to keep using the webauthn.Client can convert its errors using
+// client.ApiError.AsError instead.
+package webauthn
+
+import (
+	"context"
+	"github.com/teamhanko/hanko-go/webauthn"
+	"io"
+)
+
+// Client wraps a webauthn.Client and provides the same methods, returning the standard error interface.
+type Client struct {
+	client *webauthn.Client
+}
+
+// NewClient wraps the given webauthn.Client, e.g.:
+//
+//	client := webauthnv2.NewClient(webauthn.NewClient(apiUrl, secret).WithHmac(apiKeyId))
+func NewClient(client *webauthn.Client) *Client {
+	return &Client{client: client}
+}
+
+// V1 returns the wrapped webauthn.Client.
+func (c *Client) V1() *webauthn.Client {
+	return c.client
+}
+
+// InitializeRegistration initializes the registration of a new credential. See webauthn.Client.InitializeRegistration.
+func (c *Client) InitializeRegistration(requestBody *webauthn.RegistrationInitializationRequest) (*webauthn.RegistrationInitializationResponse, error) {
+	return c.InitializeRegistrationContext(context.Background(), requestBody)
+}
+
+// InitializeRegistrationContext is like InitializeRegistration but uses the given context.Context for the request to
+// the API.
+func (c *Client) InitializeRegistrationContext(ctx context.Context, requestBody *webauthn.RegistrationInitializationRequest) (*webauthn.RegistrationInitializationResponse, error) {
+	response, err := c.client.InitializeRegistrationContext(ctx, requestBody)
+	return response, err.AsError()
+}
+
+// FinalizeRegistration finalizes the registration of a new credential. See webauthn.Client.FinalizeRegistration.
+func (c *Client) FinalizeRegistration(requestBody *webauthn.RegistrationFinalizationRequest) (*webauthn.RegistrationFinalizationResponse, error) {
+	return c.FinalizeRegistrationContext(context.Background(), requestBody)
+}
+
+// FinalizeRegistrationContext is like FinalizeRegistration but uses the given context.Context for the request to the
+// API.
+func (c *Client) FinalizeRegistrationContext(ctx context.Context, requestBody *webauthn.RegistrationFinalizationRequest) (*webauthn.RegistrationFinalizationResponse, error) {
+	response, err := c.client.FinalizeRegistrationContext(ctx, requestBody)
+	return response, err.AsError()
+}
+
+// InitializeAuthentication initializes an authentication. See webauthn.Client.InitializeAuthentication.
+func (c *Client) InitializeAuthentication(requestBody *webauthn.AuthenticationInitializationRequest) (*webauthn.AuthenticationInitializationResponse, error) {
+	return c.InitializeAuthenticationContext(context.Background(), requestBody)
+}
+
+// InitializeAuthenticationContext is like InitializeAuthentication but uses the given context.Context for the request
+// to the API.
+func (c *Client) InitializeAuthenticationContext(ctx context.Context, requestBody *webauthn.AuthenticationInitializationRequest) (*webauthn.AuthenticationInitializationResponse, error) {
+	response, err := c.client.InitializeAuthenticationContext(ctx, requestBody)
+	return response, err.AsError()
+}
+
+// FinalizeAuthentication finalizes an authentication. See webauthn.Client.FinalizeAuthentication.
+func (c *Client) FinalizeAuthentication(requestBody *webauthn.AuthenticationFinalizationRequest) (*webauthn.AuthenticationFinalizationResponse, error) {
+	return c.FinalizeAuthenticationContext(context.Background(), requestBody)
+}
+
+// FinalizeAuthenticationContext is like FinalizeAuthentication but uses the given context.Context for the request to
+// the API.
+func (c *Client) FinalizeAuthenticationContext(ctx context.Context, requestBody *webauthn.AuthenticationFinalizationRequest) (*webauthn.AuthenticationFinalizationResponse, error) {
+	response, err := c.client.FinalizeAuthenticationContext(ctx, requestBody)
+	return response, err.AsError()
+}
+
+// InitializeTransaction initializes a transaction. See webauthn.Client.InitializeTransaction.
+func (c *Client) InitializeTransaction(requestBody *webauthn.TransactionInitializationRequest) (*webauthn.TransactionInitializationResponse, error) {
+	return c.InitializeTransactionContext(context.Background(), requestBody)
+}
+
+// InitializeTransactionContext is like InitializeTransaction but uses the given context.Context for the request to the
+// API.
+func (c *Client) InitializeTransactionContext(ctx context.Context, requestBody *webauthn.TransactionInitializationRequest) (*webauthn.TransactionInitializationResponse, error) {
+	response, err := c.client.InitializeTransactionContext(ctx, requestBody)
+	return response, err.AsError()
+}
+
+// FinalizeTransaction finalizes a transaction. See webauthn.Client.FinalizeTransaction.
+func (c *Client) FinalizeTransaction(requestBody *webauthn.TransactionFinalizationRequest) (*webauthn.TransactionFinalizationResponse, error) {
+	return c.FinalizeTransactionContext(context.Background(), requestBody)
+}
+
+// FinalizeTransactionContext is like FinalizeTransaction but uses the given context.Context for the request to the
+// API.
+func (c *Client) FinalizeTransactionContext(ctx context.Context, requestBody *webauthn.TransactionFinalizationRequest) (*webauthn.TransactionFinalizationResponse, error) {
+	response, err := c.client.FinalizeTransactionContext(ctx, requestBody)
+	return response, err.AsError()
+}
+
+// ListCredentials returns a list of webauthn.Credential. See webauthn.Client.ListCredentials.
+func (c *Client) ListCredentials(credentialQuery *webauthn.CredentialQuery) (*[]webauthn.Credential, error) {
+	return c.ListCredentialsContext(context.Background(), credentialQuery)
+}
+
+// ListCredentialsContext is like ListCredentials but uses the given context.Context for the request to the API.
+func (c *Client) ListCredentialsContext(ctx context.Context, credentialQuery *webauthn.CredentialQuery) (*[]webauthn.Credential, error) {
+	response, err := c.client.ListCredentialsContext(ctx, credentialQuery)
+	return response, err.AsError()
+}
+
+// IterateCredentials returns a webauthn.CredentialIterator over all pages of credentials. See
+// webauthn.Client.IterateCredentials.
+func (c *Client) IterateCredentials(credentialQuery *webauthn.CredentialQuery) *webauthn.CredentialIterator {
+	return c.client.IterateCredentials(credentialQuery)
+}
+
+// IterateCredentialsContext is like IterateCredentials but uses the given context.Context for the requests to the API.
+func (c *Client) IterateCredentialsContext(ctx context.Context, credentialQuery *webauthn.CredentialQuery) *webauthn.CredentialIterator {
+	return c.client.IterateCredentialsContext(ctx, credentialQuery)
+}
+
+// ListAllCredentials returns the credentials of all pages, up to the given limit. See
+// webauthn.Client.ListAllCredentials.
+func (c *Client) ListAllCredentials(credentialQuery *webauthn.CredentialQuery, limit int) ([]webauthn.Credential, error) {
+	return c.client.ListAllCredentials(credentialQuery, limit)
+}
+
+// ListAllCredentialsContext is like ListAllCredentials but uses the given context.Context for the requests to the API.
+func (c *Client) ListAllCredentialsContext(ctx context.Context, credentialQuery *webauthn.CredentialQuery, limit int) ([]webauthn.Credential, error) {
+	return c.client.ListAllCredentialsContext(ctx, credentialQuery, limit)
+}
+
+// ExportCredentials streams the credentials matching the given query to w. See webauthn.Client.ExportCredentials.
+func (c *Client) ExportCredentials(w io.Writer, format webauthn.ExportFormat, credentialQuery *webauthn.CredentialQuery) (int, error) {
+	return c.client.ExportCredentials(w, format, credentialQuery)
+}
+
+// ExportCredentialsContext is like ExportCredentials but uses the given context.Context for the requests to the API.
+func (c *Client) ExportCredentialsContext(ctx context.Context, w io.Writer, format webauthn.ExportFormat, credentialQuery *webauthn.CredentialQuery) (int, error) {
+	return c.client.ExportCredentialsContext(ctx, w, format, credentialQuery)
+}
+
+// ListCredentialDescriptors returns a webauthn.CredentialDescriptor for each credential of the user with the given ID.
+// See webauthn.Client.ListCredentialDescriptors.
+func (c *Client) ListCredentialDescriptors(userId string) ([]webauthn.CredentialDescriptor, error) {
+	return c.client.ListCredentialDescriptors(userId)
+}
+
+// ListCredentialDescriptorsContext is like ListCredentialDescriptors but uses the given context.Context for the
+// requests to the API.
+func (c *Client) ListCredentialDescriptorsContext(ctx context.Context, userId string) ([]webauthn.CredentialDescriptor, error) {
+	return c.client.ListCredentialDescriptorsContext(ctx, userId)
+}
+
+// GetCredential returns the webauthn.Credential with the specified credentialId.
+func (c *Client) GetCredential(credentialId string) (*webauthn.Credential, error) {
+	return c.GetCredentialContext(context.Background(), credentialId)
+}
+
+// GetCredentialContext is like GetCredential but uses the given context.Context for the request to the API.
+func (c *Client) GetCredentialContext(ctx context.Context, credentialId string) (*webauthn.Credential, error) {
+	response, err := c.client.GetCredentialContext(ctx, credentialId)
+	return response, err.AsError()
+}
+
+// DeleteCredential deletes the webauthn.Credential with the specified credentialId.
+func (c *Client) DeleteCredential(credentialId string) error {
+	return c.DeleteCredentialContext(context.Background(), credentialId)
+}
+
+// DeleteCredentialContext is like DeleteCredential but uses the given context.Context for the request to the API.
+func (c *Client) DeleteCredentialContext(ctx context.Context, credentialId string) error {
+	return c.client.DeleteCredentialContext(ctx, credentialId).AsError()
+}
+
+// UpdateCredential updates the webauthn.Credential with the specified credentialId. See
+// webauthn.Client.UpdateCredential.
+func (c *Client) UpdateCredential(credentialId string, requestBody *webauthn.CredentialUpdateRequest) (*webauthn.Credential, error) {
+	return c.UpdateCredentialContext(context.Background(), credentialId, requestBody)
+}
+
+// UpdateCredentialContext is like UpdateCredential but uses the given context.Context for the request to the API.
+func (c *Client) UpdateCredentialContext(ctx context.Context, credentialId string, requestBody *webauthn.CredentialUpdateRequest) (*webauthn.Credential, error) {
+	response, err := c.client.UpdateCredentialContext(ctx, credentialId, requestBody)
+	return response, err.AsError()
+}
+
+// DeleteCredentials deletes the credentials with the given IDs. See webauthn.Client.DeleteCredentials.
+func (c *Client) DeleteCredentials(credentialIds []string) *webauthn.BulkReport {
+	return c.client.DeleteCredentials(credentialIds)
+}
+
+// DeleteCredentialsContext is like DeleteCredentials but uses the given context.Context for the requests to the API.
+func (c *Client) DeleteCredentialsContext(ctx context.Context, credentialIds []string) *webauthn.BulkReport {
+	return c.client.DeleteCredentialsContext(ctx, credentialIds)
+}
+
+// RenameCredentials renames the credentials with the given IDs. See webauthn.Client.RenameCredentials.
+func (c *Client) RenameCredentials(names map[string]string) *webauthn.BulkReport {
+	return c.client.RenameCredentials(names)
+}
+
+// RenameCredentialsContext is like RenameCredentials but uses the given context.Context for the requests to the API.
+func (c *Client) RenameCredentialsContext(ctx context.Context, names map[string]string) *webauthn.BulkReport {
+	return c.client.RenameCredentialsContext(ctx, names)
+}
+
+// DeleteAllCredentialsForUser deletes all credentials of a user. See webauthn.Client.DeleteAllCredentialsForUser.
+func (c *Client) DeleteAllCredentialsForUser(userId string) (*webauthn.BulkReport, error) {
+	return c.client.DeleteAllCredentialsForUser(userId)
+}
+
+// DeleteAllCredentialsForUserContext is like DeleteAllCredentialsForUser but uses the given context.Context for the
+// requests to the API.
+func (c *Client) DeleteAllCredentialsForUserContext(ctx context.Context, userId string) (*webauthn.BulkReport, error) {
+	return c.client.DeleteAllCredentialsForUserContext(ctx, userId)
+}
@@ -0,0 +1,69 @@
+package webauthn
+
+import (
+	"encoding/json"
+	"errors"
+	hankoClient "github.com/teamhanko/hanko-go/client"
+	"github.com/teamhanko/hanko-go/webauthn"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const testApiSecret = "test"
+
+func runTestApi(status int, response interface{}) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(response)
+	}))
+}
+
+func TestClient_NilErrorOnSuccess(t *testing.T) {
+	ts := runTestApi(http.StatusOK, &webauthn.Credential{Id: "test"})
+	defer ts.Close()
+	client := NewClient(webauthn.NewClient(ts.URL, testApiSecret).WithoutLogs())
+
+	var err error
+	_, err = client.GetCredential("test")
+	if err != nil {
+		t.Errorf("expected nil error, got %#v", err)
+	}
+
+	err = client.DeleteCredential("test")
+	if err != nil {
+		t.Errorf("expected nil error, got %#v", err)
+	}
+}
+
+func TestClient_ApiErrorOnFailure(t *testing.T) {
+	ts := runTestApi(http.StatusNotFound, &hankoClient.ApiError{Message: "not found", StatusCode: http.StatusNotFound})
+	defer ts.Close()
+	client := NewClient(webauthn.NewClient(ts.URL, testApiSecret).WithoutLogs())
+
+	_, err := client.GetCredential("test")
+	var apiErr *hankoClient.ApiError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected an ApiError, got %#v", err)
+	}
+	if apiErr.StatusCode != http.StatusNotFound || !hankoClient.IsNotFound(err) {
+		t.Errorf("got %v, want a not found error", apiErr)
+	}
+}
+
+func TestClient_V1Style(t *testing.T) {
+	ts := runTestApi(http.StatusOK, &webauthn.Credential{Id: "test"})
+	defer ts.Close()
+	client := NewClient(webauthn.NewClient(ts.URL, testApiSecret).WithoutLogs())
+
+	_, apiErr := client.V1().GetCredential("test")
+	if apiErr != nil {
+		t.Errorf("expected nil api error, got %#v", apiErr)
+	}
+
+	var err error = apiErr.AsError()
+	if err != nil {
+		t.Errorf("expected nil error, got %#v", err)
+	}
+}